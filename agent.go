@@ -4,7 +4,6 @@ package syndicate
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -135,7 +134,7 @@ func (b *BaseAgent) processWithTools(ctx context.Context, messages []Message, to
 
 	// If the response indicates that tool calls are required, execute them.
 	if choice.FinishReason == FinishReasonToolCalls {
-		if err := b.handleToolCalls(choice.Message.ToolCalls); err != nil {
+		if err := b.handleToolCalls(ctx, choice.Message.ToolCalls); err != nil {
 			return "", err
 		}
 		b.mutex.Lock()
@@ -159,13 +158,14 @@ func (b *BaseAgent) processWithTools(ctx context.Context, messages []Message, to
 
 // handleToolCalls executes each tool call concurrently and collects their results.
 // It updates the agent's memory with the tool results and handles errors during execution.
-func (b *BaseAgent) handleToolCalls(toolCalls []ToolCall) error {
+func (b *BaseAgent) handleToolCalls(ctx context.Context, toolCalls []ToolCall) error {
 	var wg sync.WaitGroup
 
 	type toolResult struct {
 		CallID  string
 		Name    string
 		Content string
+		Parts   []ContentPart
 		Error   error
 	}
 
@@ -180,23 +180,19 @@ func (b *BaseAgent) handleToolCalls(toolCalls []ToolCall) error {
 
 			tool, exists := b.tools[call.Name]
 			if !exists {
-				results[i].Error = fmt.Errorf("tool %s not found", call.Name)
+				results[i].Error = fmt.Errorf("%w: %s", ErrToolNotFound, call.Name)
 				return
 			}
 
-			result, err := tool.Execute(call.Args)
+			// Run through runTool so a panicking tool becomes an error instead of crashing the process,
+			// and tools declaring a timeout through TimeoutTool are bounded like in a ToolRegistry.
+			result, err := runTool(ctx, tool, call.Args)
 			if err != nil {
 				results[i].Error = fmt.Errorf("error executing tool %s: %w", call.Name, err)
 				return
 			}
 
-			resultBytes, err := json.Marshal(result)
-			if err != nil {
-				results[i].Error = fmt.Errorf("error marshalling tool result: %w", err)
-				return
-			}
-
-			results[i].Content = string(resultBytes)
+			results[i].Content, results[i].Parts, results[i].Error = formatToolResult(result, nil)
 		}(i, call)
 	}
 
@@ -210,6 +206,7 @@ func (b *BaseAgent) handleToolCalls(toolCalls []ToolCall) error {
 		b.memory.Add(Message{
 			Role:    RoleTool,
 			Content: r.Content,
+			Parts:   r.Parts,
 			Name:    r.Name,
 			ToolID:  r.CallID,
 		})
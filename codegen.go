@@ -0,0 +1,290 @@
+package syndicate
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateGoStruct generates Go source declaring a struct named typeName that matches the object schema d,
+// with json tags and the description, enum and format tags understood by GenerateSchema.
+// Nested objects become additional named struct types, arrays become slices, and $ref targets found under
+// $defs or definitions become named types of their own. Keywords without a Go counterpart, such as oneOf,
+// are emitted as any with a comment. The returned source holds declarations only, without a package clause.
+func (d *Definition) GenerateGoStruct(typeName string) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("cannot generate Go struct for nil definition")
+	}
+	if !token.IsIdentifier(typeName) || !token.IsExported(typeName) {
+		return "", fmt.Errorf("invalid type name '%s': must be an exported Go identifier", typeName)
+	}
+	if d.Type != Object || len(d.Properties) == 0 && d.describesMap() {
+		return "", fmt.Errorf("cannot generate Go struct for schema of type '%s'", d.Type)
+	}
+
+	g := &goStructGenerator{
+		root:  d,
+		names: map[string]bool{typeName: true},
+		refs:  make(map[string]string),
+	}
+	g.mapRootRefs(typeName)
+	g.writeStruct(typeName, d)
+	for len(g.pending) > 0 {
+		next := g.pending[0]
+		g.pending = g.pending[1:]
+		g.writeStruct(next.name, next.def)
+	}
+
+	source, err := format.Source([]byte(g.out.String()))
+	if err != nil {
+		return "", fmt.Errorf("error formatting generated Go struct: %w", err)
+	}
+	return string(source), nil
+}
+
+// goStructGenerator holds the state shared while generating the struct types of a single schema.
+type goStructGenerator struct {
+	root    *Definition       // Schema whose $defs and definitions resolve $ref.
+	out     strings.Builder   // Generated declarations.
+	names   map[string]bool   // Type names already taken.
+	refs    map[string]string // Type names generated for $ref targets, keyed by reference.
+	pending []pendingStruct   // Nested struct types still to be written.
+}
+
+// pendingStruct is a nested object schema waiting to be written as a named struct type.
+type pendingStruct struct {
+	name string
+	def  *Definition
+}
+
+// writeStruct writes the declaration of a struct type named name for the object schema def.
+func (g *goStructGenerator) writeStruct(name string, def *Definition) {
+	if def.Description != "" {
+		fmt.Fprintf(&g.out, "// %s\n", commentText(def.Description))
+	}
+	fmt.Fprintf(&g.out, "type %s struct {\n", name)
+
+	required := make(map[string]bool, len(def.Required))
+	for _, property := range def.Required {
+		required[property] = true
+	}
+	fields := make(map[string]bool, len(def.Properties))
+	for _, property := range propertyNames(def) {
+		prop := def.Properties[property]
+		field := g.uniqueName(goIdentifier(property), fields)
+		goType, comment := g.goType(name+field, &prop, true)
+
+		tag := property
+		if !required[property] {
+			tag += ",omitempty"
+		}
+		tags := []string{"json:" + strconv.Quote(tag)}
+		if prop.Description != "" {
+			tags = append(tags, "description:"+strconv.Quote(prop.Description))
+		}
+		if len(prop.Enum) > 0 {
+			var values []string
+			for _, value := range prop.Enum {
+				// A null member comes from nullability, which the pointer type already expresses.
+				if value != nil {
+					values = append(values, fmt.Sprint(value))
+				}
+			}
+			tags = append(tags, "enum:"+strconv.Quote(strings.Join(values, ",")))
+		}
+		if prop.Format != "" {
+			tags = append(tags, "format:"+strconv.Quote(prop.Format))
+		}
+		if required[property] {
+			tags = append(tags, `required:"true"`)
+		}
+
+		fmt.Fprintf(&g.out, "\t%s %s %s", field, goType, structTag(strings.Join(tags, " ")))
+		if comment != "" {
+			fmt.Fprintf(&g.out, " // %s", comment)
+		}
+		g.out.WriteString("\n")
+	}
+	g.out.WriteString("}\n\n")
+}
+
+// goType returns the Go type for def, queueing a named struct type derived from name for nested objects.
+// field reports whether the type is used directly by a struct field, where nullable values and referenced
+// structs become pointers. The returned comment notes keywords that could not be represented.
+func (g *goStructGenerator) goType(name string, def *Definition, field bool) (string, string) {
+	// A nullable reference is written as anyOf [{$ref}, {"type": "null"}]; the pointer already allows nil.
+	if len(def.AnyOf) == 2 && def.AnyOf[1].Type == Null && def.AnyOf[0].Ref != "" {
+		def = &def.AnyOf[0]
+	}
+	if def.Ref != "" {
+		target, ok := lookupRef(g.root, def.Ref)
+		if !ok {
+			return "any", fmt.Sprintf("unresolved reference %s", def.Ref)
+		}
+		goType, comment := g.refType(def.Ref, target)
+		if field && target.Type == Object && !strings.HasPrefix(goType, "map[") {
+			goType = "*" + goType
+		}
+		return goType, comment
+	}
+	switch {
+	case len(def.OneOf) > 0:
+		return "any", "oneOf is not supported"
+	case len(def.AnyOf) > 0:
+		return "any", "anyOf is not supported"
+	case len(def.AllOf) > 0:
+		return "any", "allOf is not supported"
+	}
+
+	var goType, comment string
+	switch def.Type {
+	case String:
+		goType = "string"
+	case Integer:
+		goType = "int"
+	case Number:
+		goType = "float64"
+	case Boolean:
+		goType = "bool"
+	case Array:
+		goType = "[]any"
+		if def.Items != nil {
+			var elem string
+			elem, comment = g.goType(name+"Item", def.Items, false)
+			goType = "[]" + elem
+		}
+		return goType, comment
+	case Object:
+		if len(def.Properties) == 0 {
+			if def.describesMap() {
+				var elem string
+				elem, comment = g.goType(name+"Value", def.AdditionalProperties.Schema, false)
+				return "map[string]" + elem, comment
+			}
+			return "map[string]any", ""
+		}
+		goType = g.uniqueName(name, g.names)
+		g.pending = append(g.pending, pendingStruct{name: goType, def: def})
+		if field && def.Nullable {
+			goType = "*" + goType
+		}
+		return goType, ""
+	default:
+		return "any", ""
+	}
+	if field && def.Nullable {
+		goType = "*" + goType
+	}
+	return goType, comment
+}
+
+// mapRootRefs maps references to the root schema itself onto typeName. GenerateSchema describes a recursive
+// root type both inline and under $defs, so a definition named typeName, or one matching the root apart
+// from document keywords, is the root struct and must not become a second copy of it.
+func (g *goStructGenerator) mapRootRefs(typeName string) {
+	root := *g.root
+	root.Schema, root.Title, root.Defs, root.Definitions = "", "", nil, nil
+	for prefix, defs := range map[string]map[string]Definition{"#/$defs/": g.root.Defs, "#/definitions/": g.root.Definitions} {
+		for name, def := range defs {
+			def.Title = ""
+			if name == typeName || reflect.DeepEqual(def, root) {
+				g.refs[prefix+name] = typeName
+			}
+		}
+	}
+}
+
+// refType returns the Go type generated for the $ref target, generating it on first use.
+func (g *goStructGenerator) refType(ref string, target *Definition) (string, string) {
+	if goType, ok := g.refs[ref]; ok {
+		return goType, ""
+	}
+	name := goIdentifier(ref[strings.LastIndex(ref, "/")+1:])
+	if target.Type != Object || len(target.Properties) == 0 {
+		// Guard against references that reach themselves without passing through a struct.
+		g.refs[ref] = "any"
+		goType, comment := g.goType(name, target, false)
+		g.refs[ref] = goType
+		return goType, comment
+	}
+	goType := g.uniqueName(name, g.names)
+	g.refs[ref] = goType
+	g.pending = append(g.pending, pendingStruct{name: goType, def: target})
+	return goType, ""
+}
+
+// uniqueName returns name, or name with a numeric suffix when it is already taken, and marks the result as taken.
+func (g *goStructGenerator) uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// propertyNames returns the property names of def in their recorded x-order, or sorted when none was recorded.
+func propertyNames(def *Definition) []string {
+	names := make([]string, 0, len(def.Properties))
+	seen := make(map[string]bool, len(def.Properties))
+	for _, name := range def.PropertyOrder {
+		if _, ok := def.Properties[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	var rest []string
+	for name := range def.Properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// goInitialisms are words kept in upper case when converting property names to Go identifiers.
+var goInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goIdentifier converts a property name such as "user_id" into an exported Go identifier such as "UserID".
+func goIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	identifier := b.String()
+	if !token.IsExported(identifier) {
+		identifier = "Field" + identifier
+	}
+	return identifier
+}
+
+// structTag returns tag as a Go string literal, using a raw string unless the tag contains a backquote.
+func structTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// commentText flattens text onto a single line so it can be used in a line comment.
+func commentText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
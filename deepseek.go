@@ -30,9 +30,20 @@ func mapToDeepseekMessages(messages []Message) []deepseek.ChatCompletionMessage
 		if strings.EqualFold(m.Role, RoleSystem) {
 			role = RoleUser
 		}
+		content := m.Content
+		if len(m.Parts) > 0 {
+			// Deepseek solo acepta texto, así que se concatenan las partes de texto.
+			var texts []string
+			for _, p := range m.Parts {
+				if p.Type == ContentPartText {
+					texts = append(texts, p.Text)
+				}
+			}
+			content = strings.Join(texts, "\n")
+		}
 		msgs[i] = deepseek.ChatCompletionMessage{
 			Role:    role,
-			Content: m.Content,
+			Content: content,
 		}
 	}
 	return msgs
@@ -3,6 +3,7 @@ package syndicate
 import (
 	"context"
 	"encoding/json"
+	"time"
 )
 
 // Role constants define standard message roles across different providers
@@ -37,11 +38,25 @@ type ChatCompletionRequest struct {
 
 // Message represents a chat message with standardized fields.
 type Message struct {
-	Role      string     // One of RoleSystem, RoleUser, RoleAssistant, or RoleTool.
-	Content   string     // The textual content of the message.
-	Name      string     // Optional identifier for the sender.
-	ToolCalls []ToolCall // Optional tool calls made by the assistant.
-	ToolID    string     // For tool responses, references the original tool call.
+	Role      string        // One of RoleSystem, RoleUser, RoleAssistant, or RoleTool.
+	Content   string        // The textual content of the message.
+	Parts     []ContentPart // Optional multi-part content, sent instead of Content when present.
+	Name      string        // Optional identifier for the sender.
+	ToolCalls []ToolCall    // Optional tool calls made by the assistant.
+	ToolID    string        // For tool responses, references the original tool call.
+}
+
+// ContentPart types supported in multi-part message content.
+const (
+	ContentPartText     = "text"
+	ContentPartImageURL = "image_url"
+)
+
+// ContentPart is one piece of multi-part message content, such as text or an image.
+type ContentPart struct {
+	Type     string // ContentPartText or ContentPartImageURL.
+	Text     string // Text of a ContentPartText part.
+	ImageURL string // URL or data URI of a ContentPartImageURL part.
 }
 
 // ToolCall represents a tool invocation request.
@@ -59,9 +74,39 @@ type ToolDefinition struct {
 }
 
 // Tool defines the interface for executable tools.
+// Execute receives the context of the agent's request so tools can honor cancellation and deadlines.
+// A result implementing io.Reader, such as a large report, is streamed into the message content
+// instead of being JSON marshalled, and closed afterwards if it implements io.Closer.
 type Tool interface {
 	GetDefinition() ToolDefinition
-	Execute(args json.RawMessage) (interface{}, error)
+	Execute(ctx context.Context, args json.RawMessage) (interface{}, error)
+}
+
+// TimeoutTool can be implemented by tools that need their own execution deadline.
+// A ToolRegistry runs such tools with a context bounded by the returned duration; zero or
+// negative values leave the caller's context unchanged.
+type TimeoutTool interface {
+	Timeout() time.Duration
+}
+
+// ReadOnlyTool can be implemented by tools that report whether they are free of side effects,
+// so callers can run read-only tools without confirmation while gating the others.
+// Tools that do not implement it are assumed to mutate state.
+type ReadOnlyTool interface {
+	ReadOnly() bool
+}
+
+// ContentPartsResult can be implemented by values returned from Tool.Execute to hand mixed content,
+// such as text and images, back to multimodal models. When implemented, the parts are sent as the
+// tool message content instead of a single string.
+type ContentPartsResult interface {
+	ContentParts() []ContentPart
+}
+
+// ResultStringer can be implemented by values returned from Tool.Execute to control how the
+// result is presented to the model. When implemented, ResultString is used instead of JSON marshalling.
+type ResultStringer interface {
+	ResultString() string
 }
 
 // ResponseFormat specifies how the LLM should format its response.
@@ -0,0 +1,61 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ToolHandler executes a tool call identified by name with the given JSON arguments.
+type ToolHandler func(ctx context.Context, name string, args json.RawMessage) (any, error)
+
+// ToolMiddleware wraps a ToolHandler to add cross-cutting behavior such as logging,
+// metrics or timing around every tool invocation dispatched by a ToolRegistry.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// chainMiddleware wraps handler with the given middleware so the first one is the outermost.
+func chainMiddleware(handler ToolHandler, middleware []ToolMiddleware) ToolHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// RetryMiddleware retries tool calls that fail with a transient error, such as a dropped connection.
+// Each call is attempted up to attempts times; before attempt n+1, the middleware waits backoff(n),
+// so backoff(1) is the delay before the first retry. A nil backoff retries immediately.
+// retryable decides which errors are worth retrying; when nil, every error is retried except unknown
+// tools, invalid arguments and panics, which fail the same way each time, and context.Canceled or
+// context.DeadlineExceeded returned by the tool. Calls are never retried once ctx itself ends,
+// and a context that ends while waiting stops the retries with the context's error.
+func RetryMiddleware(attempts int, backoff func(int) time.Duration, retryable func(error) bool) ToolMiddleware {
+	if retryable == nil {
+		retryable = func(err error) bool {
+			var validationErr *ValidationError
+			return !errors.Is(err, ErrToolNotFound) && !errors.As(err, &validationErr) &&
+				!errors.Is(err, ErrToolPanicked) &&
+				!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args json.RawMessage) (any, error) {
+			for attempt := 1; ; attempt++ {
+				result, err := next(ctx, name, args)
+				if err == nil || attempt >= attempts || ctx.Err() != nil || !retryable(err) {
+					return result, err
+				}
+				if backoff == nil {
+					continue
+				}
+				timer := time.NewTimer(backoff(attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
 // OpenAIClient implements the LLMClient interface using the OpenAI SDK.
@@ -34,16 +37,38 @@ func NewOpenAIClient(apiKey string) LLMClient {
 func mapToOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
 	var msgs []openai.ChatCompletionMessage
 	for _, m := range messages {
-		msgs = append(msgs, openai.ChatCompletionMessage{
+		msg := openai.ChatCompletionMessage{
 			Role:       m.Role,
 			Name:       m.Name,
-			Content:    m.Content,
 			ToolCallID: m.ToolID,
-		})
+		}
+		// OpenAI rejects messages that set both Content and MultiContent.
+		if len(m.Parts) > 0 {
+			msg.MultiContent = mapToOpenAIParts(m.Parts)
+		} else {
+			msg.Content = m.Content
+		}
+		msgs = append(msgs, msg)
 	}
 	return msgs
 }
 
+// mapToOpenAIParts converts internal ContentPart values into OpenAI message parts.
+func mapToOpenAIParts(parts []ContentPart) []openai.ChatMessagePart {
+	result := make([]openai.ChatMessagePart, 0, len(parts))
+	for _, p := range parts {
+		part := openai.ChatMessagePart{Type: openai.ChatMessagePartType(p.Type)}
+		switch p.Type {
+		case ContentPartImageURL:
+			part.ImageURL = &openai.ChatMessageImageURL{URL: p.ImageURL}
+		default:
+			part.Text = p.Text
+		}
+		result = append(result, part)
+	}
+	return result
+}
+
 // mapToOpenAITools converts a slice of internal ToolDefinition structs into OpenAI Tools.
 // These definitions are used to enable function calls in the API.
 func mapToOpenAITools(tools []ToolDefinition) []openai.Tool {
@@ -62,6 +87,91 @@ func mapToOpenAITools(tools []ToolDefinition) []openai.Tool {
 	return result
 }
 
+// AsOpenAITool converts a Tool into the openai.Tool wrapper expected by the go-openai client.
+func AsOpenAITool(t Tool) openai.Tool {
+	return mapToOpenAITools([]ToolDefinition{t.GetDefinition()})[0]
+}
+
+// AsOpenAITools converts several tools into openai.Tool wrappers, preserving their order.
+func AsOpenAITools(tools ...Tool) []openai.Tool {
+	defs := make([]ToolDefinition, 0, len(tools))
+	for _, t := range tools {
+		defs = append(defs, t.GetDefinition())
+	}
+	return mapToOpenAITools(defs)
+}
+
+// ToOpenAIJSONSchema converts d into the jsonschema.Definition used by go-openai, so a schema generated
+// here can be handed to code that builds go-openai requests directly. Only the fields both types share are
+// mapped: type, description, enum, properties, required, items and additionalProperties. Enum values are
+// converted to strings, and keywords go-openai does not model, such as formats, bounds and nullability, are dropped.
+func (d *Definition) ToOpenAIJSONSchema() jsonschema.Definition {
+	if d == nil {
+		return jsonschema.Definition{}
+	}
+	out := jsonschema.Definition{
+		Type:        jsonschema.DataType(d.Type),
+		Description: d.Description,
+		Required:    slices.Clone(d.Required),
+	}
+	for _, value := range d.Enum {
+		if value != nil {
+			out.Enum = append(out.Enum, fmt.Sprint(value))
+		}
+	}
+	if d.Properties != nil {
+		out.Properties = make(map[string]jsonschema.Definition, len(d.Properties))
+		for name, prop := range d.Properties {
+			out.Properties[name] = prop.ToOpenAIJSONSchema()
+		}
+	}
+	if d.Items != nil {
+		items := d.Items.ToOpenAIJSONSchema()
+		out.Items = &items
+	}
+	switch {
+	case d.describesMap():
+		out.AdditionalProperties = d.AdditionalProperties.Schema.ToOpenAIJSONSchema()
+	case d.AdditionalProperties != nil:
+		out.AdditionalProperties = !d.AdditionalProperties.forbidden()
+	}
+	return out
+}
+
+// FromOpenAIJSONSchema converts a go-openai jsonschema.Definition into a Definition, the inverse of
+// ToOpenAIJSONSchema. additionalProperties may hold a bool or a jsonschema.Definition, by value or pointer;
+// other values are ignored.
+func FromOpenAIJSONSchema(def jsonschema.Definition) *Definition {
+	out := &Definition{
+		Type:        DataType(def.Type),
+		Description: def.Description,
+		Required:    slices.Clone(def.Required),
+	}
+	for _, value := range def.Enum {
+		out.Enum = append(out.Enum, value)
+	}
+	if def.Properties != nil {
+		out.Properties = make(map[string]Definition, len(def.Properties))
+		for name, prop := range def.Properties {
+			out.Properties[name] = *FromOpenAIJSONSchema(prop)
+		}
+	}
+	if def.Items != nil {
+		out.Items = FromOpenAIJSONSchema(*def.Items)
+	}
+	switch additional := def.AdditionalProperties.(type) {
+	case bool:
+		out.AdditionalProperties = AllowAdditionalProperties(additional)
+	case jsonschema.Definition:
+		out.AdditionalProperties = AdditionalPropertiesSchema(FromOpenAIJSONSchema(additional))
+	case *jsonschema.Definition:
+		if additional != nil {
+			out.AdditionalProperties = AdditionalPropertiesSchema(FromOpenAIJSONSchema(*additional))
+		}
+	}
+	return out
+}
+
 // mapFromOpenAIToolCalls converts a slice of OpenAI ToolCall objects into the internal ToolCall structure.
 // This enables the SDK to process tool calls in a provider-agnostic manner.
 func mapFromOpenAIToolCalls(calls []openai.ToolCall) []ToolCall {
@@ -133,3 +243,64 @@ func (o *OpenAIClient) CreateChatCompletion(ctx context.Context, req ChatComplet
 	// Map the OpenAI response into our internal unified format.
 	return mapFromOpenAIResponse(resp), nil
 }
+
+// ToolCallAccumulator reassembles the tool calls of a streamed OpenAI chat completion.
+// Streaming splits each call across chunk deltas that share an index: the first delta carries
+// the ID and name, and later ones carry fragments of the arguments. It is not safe for concurrent use.
+type ToolCallAccumulator struct {
+	calls map[int]*openai.ToolCall // Calls being assembled, keyed by index.
+	last  int                      // Index of the most recent delta, used for deltas without one.
+}
+
+// NewToolCallAccumulator creates an empty ToolCallAccumulator.
+func NewToolCallAccumulator() *ToolCallAccumulator {
+	return &ToolCallAccumulator{calls: make(map[int]*openai.ToolCall)}
+}
+
+// Add merges a streamed delta into the call with the same index, concatenating its arguments.
+// Deltas without an index continue the most recent call, unless they carry an ID of their own.
+func (a *ToolCallAccumulator) Add(delta openai.ToolCall) {
+	if a.calls == nil {
+		a.calls = make(map[int]*openai.ToolCall)
+	}
+	index := a.last
+	switch {
+	case delta.Index != nil:
+		index = *delta.Index
+	case delta.ID != "" && len(a.calls) > 0 && a.calls[a.last].ID != delta.ID:
+		index = a.last + 1
+	}
+	a.last = index
+
+	call, ok := a.calls[index]
+	if !ok {
+		call = &openai.ToolCall{Type: openai.ToolTypeFunction}
+		a.calls[index] = call
+	}
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+}
+
+// Finish returns the assembled calls ordered by index, with Index cleared as in non-streamed responses.
+func (a *ToolCallAccumulator) Finish() []openai.ToolCall {
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	calls := make([]openai.ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		call := *a.calls[index]
+		call.Index = nil
+		calls = append(calls, call)
+	}
+	return calls
+}
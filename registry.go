@@ -0,0 +1,451 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrToolTimeout is returned when a tool does not finish within the timeout it declares through TimeoutTool.
+var ErrToolTimeout = errors.New("tool execution timed out")
+
+// ErrToolNotFound is returned when a call names a tool that is not registered, such as a tool name
+// hallucinated by the model. Errors wrapping it carry the requested name.
+var ErrToolNotFound = errors.New("tool not found")
+
+// ErrToolPanicked is wrapped by the *PanicError returned when a tool panics.
+var ErrToolPanicked = errors.New("tool panicked")
+
+// PanicError reports a panic recovered from a tool. Its message names only the tool and the
+// panic value, so it is safe to send back to the model; the stack trace is kept for logging.
+type PanicError struct {
+	Tool  string // Name of the tool that panicked.
+	Value any    // Value passed to panic.
+	Stack []byte // Stack trace of the panicking goroutine.
+}
+
+// Error returns the tool name and the panic value, without the stack trace.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool '%s' panicked: %v", e.Tool, e.Value)
+}
+
+// Unwrap returns ErrToolPanicked.
+func (e *PanicError) Unwrap() error {
+	return ErrToolPanicked
+}
+
+// ToolRegistry stores tools by name and dispatches tool calls to them.
+// It is safe for concurrent use.
+type ToolRegistry struct {
+	tools map[string]Tool // Registered tools identified by their names.
+	order []string        // Tool names in registration order.
+	mutex sync.RWMutex    // RWMutex to ensure thread-safe access to the registry.
+
+	validateArgs   bool // Whether arguments are checked against the tool schema before execution.
+	maxConcurrency int  // Maximum number of tool calls ExecuteToolCalls runs at once; 0 means unbounded.
+	maxResultBytes int  // Budget for serialized tool results; 0 means unlimited.
+
+	middleware []ToolMiddleware // Middleware wrapping every execution, in registration order.
+}
+
+// NewToolRegistry creates and returns an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]Tool),
+	}
+}
+
+// Register adds a tool to the registry, keyed by the name in its definition.
+// It returns an error if the name is empty or a tool with the same name is already registered.
+func (r *ToolRegistry) Register(tool Tool) error {
+	name := tool.GetDefinition().Name
+	if name == "" {
+		return errors.New("tool name cannot be empty")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.tools[name]; exists {
+		return fmt.Errorf("tool %s already registered", name)
+	}
+	r.tools[name] = tool
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get retrieves a registered tool by its name. The boolean is false if no tool is registered
+// under name; Execute reports the same case as ErrToolNotFound.
+func (r *ToolRegistry) Get(name string) (Tool, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	tool, exists := r.tools[name]
+	return tool, exists
+}
+
+// ReadOnly reports whether the tool registered under name declares itself free of side effects
+// through ReadOnlyTool. It returns false for unknown tools and for tools that do not implement it.
+func (r *ToolRegistry) ReadOnly(name string) bool {
+	tool, exists := r.Get(name)
+	if !exists {
+		return false
+	}
+	readOnly, ok := tool.(ReadOnlyTool)
+	return ok && readOnly.ReadOnly()
+}
+
+// Definitions returns the definitions of all registered tools in registration order,
+// ready to be used as the Tools of a ChatCompletionRequest.
+func (r *ToolRegistry) Definitions() []ToolDefinition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	defs := make([]ToolDefinition, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].GetDefinition())
+	}
+	return defs
+}
+
+// ToolInfo describes a registered tool for introspection, such as an endpoint listing the tools
+// available to an agent. Unlike ToolDefinition it carries the parameters as a parsed schema.
+type ToolInfo struct {
+	Name        string      `json:"name"`                  // Name of the tool.
+	Description string      `json:"description,omitempty"` // A short description of what the tool does.
+	Parameters  *Definition `json:"parameters,omitempty"`  // Parameters schema; nil if absent or invalid.
+	ReadOnly    bool        `json:"readOnly"`              // Whether the tool declares itself free of side effects.
+}
+
+// Snapshot returns a description of every registered tool in registration order. The returned values
+// are independent of the registry, so they can be serialized while tools keep being registered.
+// Parameters that cannot be parsed are left nil; Validate reports them.
+func (r *ToolRegistry) Snapshot() []ToolInfo {
+	r.mutex.RLock()
+	tools := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	r.mutex.RUnlock()
+
+	infos := make([]ToolInfo, 0, len(tools))
+	for _, tool := range tools {
+		def := tool.GetDefinition()
+		info := ToolInfo{Name: def.Name, Description: def.Description}
+		if len(def.Parameters) > 0 {
+			if params, err := ParseDefinition(def.Parameters); err == nil {
+				info.Parameters = params
+			}
+		}
+		if readOnly, ok := tool.(ReadOnlyTool); ok {
+			info.ReadOnly = readOnly.ReadOnly()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Validate checks the definition of every registered tool, so misconfiguration can be caught at startup
+// rather than on the first model call. Each tool must have a name, a description and a parameters schema
+// that parses and passes ValidateDefinition. All problems found are joined into the returned error.
+func (r *ToolRegistry) Validate() error {
+	var errs []error
+	for _, def := range r.Definitions() {
+		name := def.Name
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, errors.New("tool name cannot be empty"))
+			name = "<unnamed>"
+		}
+		if strings.TrimSpace(def.Description) == "" {
+			errs = append(errs, fmt.Errorf("tool %s has no description", name))
+		}
+		if len(def.Parameters) == 0 {
+			errs = append(errs, fmt.Errorf("tool %s has no parameters schema", name))
+			continue
+		}
+		schema, err := ParseDefinition(def.Parameters)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tool %s has an invalid parameters schema: %w", name, err))
+			continue
+		}
+		if err := ValidateDefinition(schema); err != nil {
+			errs = append(errs, fmt.Errorf("tool %s has an invalid parameters schema: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetArgsValidation enables or disables checking call arguments against each tool's
+// parameters schema with ValidateArgs before the tool is executed.
+func (r *ToolRegistry) SetArgsValidation(enabled bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.validateArgs = enabled
+}
+
+// SetMaxConcurrency limits how many tool calls ExecuteToolCalls runs at the same time.
+// A value of zero or less removes the limit.
+func (r *ToolRegistry) SetMaxConcurrency(n int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.maxConcurrency = n
+}
+
+// SetMaxResultBytes limits the size of the serialized result of every tool execution to n bytes.
+// Longer results are cut on a UTF-8 boundary and end with TruncationMarker, the whole fitting in n bytes;
+// budgets too small to hold the marker get the cut content alone. Truncation applies to the message
+// content, after JSON encoding, so a truncated result is no longer valid JSON: a string result, for
+// example, keeps its opening quote but loses the closing one. A value of zero or less removes the limit.
+func (r *ToolRegistry) SetMaxResultBytes(n int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.maxResultBytes = n
+}
+
+// Use appends middleware to the chain that wraps every tool execution.
+// Middleware runs in registration order, so the first one added is the outermost.
+func (r *ToolRegistry) Use(middleware ...ToolMiddleware) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Execute runs the tool registered under name with the provided JSON arguments,
+// passing the call through any middleware registered with Use.
+// It returns an error if the tool is not registered, the context is already done, the arguments
+// fail validation (when enabled with SetArgsValidation), or the tool fails.
+// Results exceeding the budget set with SetMaxResultBytes are returned as a TruncatedResult.
+func (r *ToolRegistry) Execute(ctx context.Context, name string, args json.RawMessage) (any, error) {
+	r.mutex.RLock()
+	middleware := r.middleware
+	limit := r.maxResultBytes
+	r.mutex.RUnlock()
+	result, err := chainMiddleware(r.execute, middleware)(ctx, name, args)
+	if err != nil || limit <= 0 {
+		return result, err
+	}
+	return truncateToolResult(result, limit)
+}
+
+// TruncationMarker ends tool results cut short to fit the budget set with SetMaxResultBytes.
+const TruncationMarker = "…[truncated]"
+
+// TruncatedResult is the serialized form of a tool result that exceeded the budget set with
+// SetMaxResultBytes, already cut and ending with TruncationMarker when the budget leaves room for it.
+type TruncatedResult string
+
+// ResultString returns the truncated content, used verbatim as the tool message content.
+func (t TruncatedResult) ResultString() string {
+	return string(t)
+}
+
+// truncateToolResult returns result unchanged when its serialized form fits in limit bytes,
+// and a TruncatedResult otherwise. Multi-part results are never truncated. An io.Reader is read
+// only up to the budget, so a large stream is never buffered in full; when it fits, its content
+// is returned as a new reader.
+func truncateToolResult(result any, limit int) (any, error) {
+	if reader, ok := result.(io.Reader); ok {
+		data, err := drainReader(io.LimitReader(reader, int64(limit)+1), reader)
+		if err != nil || len(data) <= limit {
+			return bytes.NewReader(data), err
+		}
+		return truncateContent(string(data), limit), nil
+	}
+	content, parts, err := formatToolResult(result, nil)
+	if err != nil || len(parts) > 0 || len(content) <= limit {
+		return result, err
+	}
+	return truncateContent(content, limit), nil
+}
+
+// truncateContent cuts content on a UTF-8 boundary so that, followed by TruncationMarker, it fits in limit bytes.
+// When limit cannot hold the marker, content is cut to limit bytes without it.
+func truncateContent(content string, limit int) TruncatedResult {
+	marker := TruncationMarker
+	if limit < len(marker) {
+		marker = ""
+	}
+	cut := limit - len(marker)
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return TruncatedResult(content[:cut] + marker)
+}
+
+// execute is the innermost ToolHandler: it looks up the tool, validates the arguments and runs it.
+func (r *ToolRegistry) execute(ctx context.Context, name string, args json.RawMessage) (any, error) {
+	tool, exists := r.Get(name)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	validate := r.validateArgs
+	r.mutex.RUnlock()
+	if validate {
+		if err := validateToolArgs(tool, args); err != nil {
+			return nil, fmt.Errorf("invalid arguments for tool %s: %w", name, err)
+		}
+	}
+
+	result, err := runTool(ctx, tool, args)
+	if err != nil {
+		return nil, fmt.Errorf("error executing tool %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// runTool executes the tool, enforcing the timeout it declares through TimeoutTool.
+// When the timeout expires the call returns ErrToolTimeout without waiting for the tool to finish.
+func runTool(ctx context.Context, tool Tool, args json.RawMessage) (any, error) {
+	timeoutTool, ok := tool.(TimeoutTool)
+	if !ok || timeoutTool.Timeout() <= 0 {
+		return safeExecute(ctx, tool, args)
+	}
+
+	timeout := timeoutTool.Timeout()
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := safeExecute(toolCtx, tool, args)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		if out.err != nil && ctx.Err() == nil && errors.Is(toolCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s", ErrToolTimeout, timeout)
+		}
+		return out.result, out.err
+	case <-toolCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w after %s", ErrToolTimeout, timeout)
+	}
+}
+
+// safeExecute calls tool.Execute and converts a panic into a *PanicError carrying
+// the recovered value and the stack trace, so a faulty tool cannot crash the process.
+func safeExecute(ctx context.Context, tool Tool, args json.RawMessage) (result any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = nil
+			err = &PanicError{Tool: tool.GetDefinition().Name, Value: recovered, Stack: debug.Stack()}
+		}
+	}()
+	return tool.Execute(ctx, args)
+}
+
+// ExecuteToolCalls runs the given tool calls concurrently through the registry, bounded by its
+// maximum concurrency, and returns one tool-role message per call in the original call order.
+// A failing call does not abort the batch: its error is reported as the message content instead.
+// The returned error is non-nil only if the context ends before every call has completed.
+func ExecuteToolCalls(ctx context.Context, reg *ToolRegistry, calls []ToolCall) ([]Message, error) {
+	if reg == nil {
+		return nil, errors.New("tool registry is nil")
+	}
+
+	reg.mutex.RLock()
+	limit := reg.maxConcurrency
+	reg.mutex.RUnlock()
+	if limit <= 0 || limit > len(calls) {
+		limit = len(calls)
+	}
+
+	messages := make([]Message, len(calls))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			content, parts, err := formatToolResult(reg.Execute(ctx, call.Name, call.Args))
+			if err != nil {
+				content = fmt.Sprintf("error: %v", err)
+			}
+			messages[i] = Message{
+				Role:    RoleTool,
+				Content: content,
+				Parts:   parts,
+				Name:    call.Name,
+				ToolID:  call.ID,
+			}
+		}(i, call)
+	}
+
+	wg.Wait()
+	return messages, ctx.Err()
+}
+
+// formatToolResult converts the outcome of a tool execution into message content.
+// Results implementing ContentPartsResult provide multi-part content, results implementing
+// ResultStringer provide their own text, an io.Reader is read to the end and used verbatim,
+// and anything else is JSON marshalled.
+func formatToolResult(result any, err error) (string, []ContentPart, error) {
+	if err != nil {
+		return "", nil, err
+	}
+	if multi, ok := result.(ContentPartsResult); ok {
+		if parts := multi.ContentParts(); len(parts) > 0 {
+			return "", parts, nil
+		}
+	}
+	if stringer, ok := result.(ResultStringer); ok {
+		return stringer.ResultString(), nil, nil
+	}
+	if reader, ok := result.(io.Reader); ok {
+		data, err := drainReader(reader, reader)
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading tool result: %w", err)
+		}
+		return string(data), nil, nil
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", nil, fmt.Errorf("error marshalling tool result: %w", err)
+	}
+	return string(resultBytes), nil, nil
+}
+
+// drainReader reads r to the end and then closes source if it implements io.Closer,
+// so readers backed by files or connections are released once their content is consumed.
+func drainReader(r io.Reader, source io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if closer, ok := source.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return data, err
+}
+
+// validateToolArgs checks args against the parameters schema declared by the tool, if any.
+func validateToolArgs(tool Tool, args json.RawMessage) error {
+	params := tool.GetDefinition().Parameters
+	if len(params) == 0 {
+		return nil
+	}
+	def, err := ParseDefinition(params)
+	if err != nil {
+		return err
+	}
+	return ValidateArgs(def, args)
+}
@@ -0,0 +1,871 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// echoTool returns its arguments as a string.
+func echoTool(name string) Tool {
+	return echoFunc{name: name}
+}
+
+// echoFunc implements Tool directly, without any adapter.
+type echoFunc struct {
+	name string
+}
+
+func (t echoFunc) GetDefinition() ToolDefinition {
+	return ToolDefinition{Name: t.name, Description: "Echoes its arguments."}
+}
+
+func (t echoFunc) Execute(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	return string(args), nil
+}
+
+func TestToolRegistryRegister(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(echoTool("echo")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(echoTool("echo")); err == nil {
+		t.Error("expected an error for a duplicate name")
+	}
+	if err := reg.Register(echoTool("")); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+	if err := reg.Register(echoTool("other")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	var names []string
+	for _, def := range reg.Definitions() {
+		names = append(names, def.Name)
+	}
+	if strings.Join(names, ",") != "echo,other" {
+		t.Errorf("definitions = %v, want registration order", names)
+	}
+	if tool, ok := reg.Get("other"); !ok || tool.GetDefinition().Name != "other" {
+		t.Errorf("Get = %v, %t", tool, ok)
+	}
+}
+
+func TestToolRegistryExecute(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(echoTool("echo")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	result, err := reg.Execute(context.Background(), "echo", json.RawMessage(`{"a":1}`))
+	if err != nil || result != `{"a":1}` {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+	if _, err := reg.Execute(context.Background(), "missing", nil); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Execute error = %v, want one naming the tool", err)
+	}
+}
+
+// slowTool waits for delay or for its context to end.
+type slowTool struct {
+	delay   time.Duration
+	timeout time.Duration
+}
+
+func (t slowTool) GetDefinition() ToolDefinition {
+	return ToolDefinition{Name: "slow", Description: "Takes its time."}
+}
+
+func (t slowTool) Execute(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	select {
+	case <-time.After(t.delay):
+		return "done", nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t slowTool) Timeout() time.Duration {
+	return t.timeout
+}
+
+func TestToolRegistryCancellation(t *testing.T) {
+	reg := NewToolRegistry()
+	var calls atomic.Int32
+	if err := reg.Register(countingTool{calls: &calls}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(slowTool{delay: time.Second}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := reg.Execute(ctx, "count", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute error = %v, want context.Canceled", err)
+	}
+	if calls.Load() != 0 {
+		t.Error("the tool ran although the context was already canceled")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := reg.Execute(ctx, "slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Execute returned after %s, want it to follow the context", elapsed)
+	}
+}
+
+// countingTool counts its executions.
+type countingTool struct {
+	calls *atomic.Int32
+}
+
+func (t countingTool) GetDefinition() ToolDefinition {
+	return ToolDefinition{Name: "count", Description: "Counts its calls."}
+}
+
+func (t countingTool) Execute(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	t.calls.Add(1)
+	return nil, nil
+}
+
+func TestNewTypedTool(t *testing.T) {
+	type args struct {
+		City string `json:"city" description:"City name"`
+		Days int    `json:"days,omitempty"`
+	}
+	tool := NewTypedTool("forecast", "Weather forecast.", func(ctx context.Context, in args) (string, error) {
+		return fmt.Sprintf("%s for %d days", in.City, in.Days), nil
+	})
+	def := tool.GetDefinition()
+	if def.Name != "forecast" || def.Description != "Weather forecast." {
+		t.Errorf("definition = %+v", def)
+	}
+	assertJSON(t, def.Parameters, `{"type":"object","properties":{"city":{"type":"string","description":"City name"},"days":{"type":"integer"}},"required":["city"],"additionalProperties":false}`)
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{"city":"Paris","days":3}`))
+	if err != nil || result != "Paris for 3 days" {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+	if _, err := tool.Execute(context.Background(), json.RawMessage(`{"city":`)); err == nil {
+		t.Error("expected an error for malformed arguments")
+	}
+
+	broken := NewTypedTool("broken", "Cannot be described.", func(ctx context.Context, in struct{ C chan int }) (string, error) {
+		return "", nil
+	})
+	if _, err := broken.Execute(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Execute error = %v, want the schema error", err)
+	}
+}
+
+func TestToolRegistryArgsValidation(t *testing.T) {
+	params, err := GenerateRawSchema(struct {
+		City string `json:"city"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateRawSchema: %v", err)
+	}
+	var calls atomic.Int32
+	reg := NewToolRegistry()
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "weather", Parameters: params}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		calls.Add(1)
+		return "sunny", nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := reg.Execute(context.Background(), "weather", json.RawMessage(`{}`)); err != nil {
+		t.Errorf("Execute without validation: %v", err)
+	}
+
+	reg.SetArgsValidation(true)
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "missing required", args: `{}`, want: "invalid argument 'city'"},
+		{name: "wrong type", args: `{"city":1}`, want: "invalid argument 'city'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			_, err := reg.Execute(context.Background(), "weather", json.RawMessage(tt.args))
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Execute error = %v, want %q", err, tt.want)
+			}
+			if calls.Load() != 0 {
+				t.Error("the tool ran with invalid arguments")
+			}
+		})
+	}
+	if result, err := reg.Execute(context.Background(), "weather", json.RawMessage(`{"city":"Paris"}`)); err != nil || result != "sunny" {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+}
+
+func TestAsOpenAITool(t *testing.T) {
+	tool := AsOpenAITool(echoTool("a"))
+	if tool.Type != openai.ToolTypeFunction || tool.Function == nil || tool.Function.Name != "a" || !tool.Function.Strict {
+		t.Errorf("tool = %+v", tool)
+	}
+
+	tools := AsOpenAITools(echoTool("a"), echoTool("b"))
+	if len(tools) != 2 || tools[0].Function.Name != "a" || tools[1].Function.Name != "b" {
+		t.Errorf("tools = %+v", tools)
+	}
+}
+
+func TestExecuteToolCalls(t *testing.T) {
+	reg := NewToolRegistry()
+	reg.SetMaxConcurrency(2)
+	var running, peak atomic.Int32
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "work"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return string(args), nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var calls []ToolCall
+	for i := 0; i < 6; i++ {
+		calls = append(calls, ToolCall{ID: fmt.Sprint("call", i), Name: "work", Args: json.RawMessage(fmt.Sprint(i))})
+	}
+	calls = append(calls, ToolCall{ID: "missing", Name: "missing"})
+
+	messages, err := ExecuteToolCalls(context.Background(), reg, calls)
+	if err != nil {
+		t.Fatalf("ExecuteToolCalls: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if want := fmt.Sprintf("%q", fmt.Sprint(i)); messages[i].Content != want || messages[i].ToolID != calls[i].ID || messages[i].Role != RoleTool {
+			t.Errorf("message %d = %+v, want content %s", i, messages[i], want)
+		}
+	}
+	if !strings.HasPrefix(messages[6].Content, "error: ") {
+		t.Errorf("unknown tool message = %q", messages[6].Content)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("%d calls ran at once, want at most 2", peak.Load())
+	}
+
+	if _, err := ExecuteToolCalls(context.Background(), nil, calls); err == nil {
+		t.Error("expected an error for a nil registry")
+	}
+}
+
+// TestToolRegistryConcurrent registers and executes tools from many goroutines; run it with -race.
+func TestToolRegistryConcurrent(t *testing.T) {
+	reg := NewToolRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprint("tool", i)
+			if err := reg.Register(echoTool(name)); err != nil {
+				t.Errorf("Register: %v", err)
+				return
+			}
+			reg.SetMaxConcurrency(4)
+			if _, err := reg.Execute(context.Background(), name, json.RawMessage(`{}`)); err != nil {
+				t.Errorf("Execute: %v", err)
+			}
+			reg.Definitions()
+		}(i)
+	}
+	wg.Wait()
+	if len(reg.Definitions()) != 16 {
+		t.Errorf("got %d definitions, want 16", len(reg.Definitions()))
+	}
+}
+
+// compactResult renders itself as text.
+type compactResult struct {
+	Temp int `json:"temp"`
+}
+
+func (r compactResult) ResultString() string {
+	return fmt.Sprintf("%d°C", r.Temp)
+}
+
+func TestExecuteToolCallsResultFormatting(t *testing.T) {
+	type plainResult struct {
+		Temp int `json:"temp"`
+	}
+	reg := NewToolRegistry()
+	for _, tool := range []Tool{
+		NewToolFunc(ToolDefinition{Name: "compact"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			return compactResult{Temp: 21}, nil
+		}),
+		NewToolFunc(ToolDefinition{Name: "plain"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			return plainResult{Temp: 21}, nil
+		}),
+	} {
+		if err := reg.Register(tool); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	messages, err := ExecuteToolCalls(context.Background(), reg, []ToolCall{{ID: "1", Name: "compact"}, {ID: "2", Name: "plain"}})
+	if err != nil {
+		t.Fatalf("ExecuteToolCalls: %v", err)
+	}
+	if messages[0].Content != "21°C" || messages[1].Content != `{"temp":21}` {
+		t.Errorf("contents = %q, %q", messages[0].Content, messages[1].Content)
+	}
+}
+
+func TestToolRegistryMiddleware(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(echoTool("echo")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	var order []string
+	trace := func(label string) ToolMiddleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, name string, args json.RawMessage) (any, error) {
+				order = append(order, label+">"+name)
+				result, err := next(ctx, name, args)
+				order = append(order, "<"+label)
+				return result, err
+			}
+		}
+	}
+	reg.Use(trace("outer"), trace("inner"))
+	if _, err := reg.Execute(context.Background(), "echo", nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := strings.Join(order, " "); got != "outer>echo inner>echo <inner <outer" {
+		t.Errorf("middleware order = %s", got)
+	}
+}
+
+func TestToolRegistryLoggingMiddleware(t *testing.T) {
+	type entry struct {
+		name     string
+		duration time.Duration
+	}
+	var log []entry
+	reg := NewToolRegistry()
+	if err := reg.Register(slowTool{delay: 5 * time.Millisecond}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(echoTool("echo")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	reg.Use(func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args json.RawMessage) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, name, args)
+			log = append(log, entry{name: name, duration: time.Since(start)})
+			return result, err
+		}
+	})
+	for _, name := range []string{"slow", "echo", "missing"} {
+		_, _ = reg.Execute(context.Background(), name, nil)
+	}
+	if len(log) != 3 || log[0].name != "slow" || log[1].name != "echo" || log[2].name != "missing" {
+		t.Fatalf("log = %+v", log)
+	}
+	if log[0].duration < 5*time.Millisecond {
+		t.Errorf("slow call logged %s, want at least its delay", log[0].duration)
+	}
+}
+
+func TestToolRegistryTimeout(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(slowTool{delay: time.Second, timeout: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	start := time.Now()
+	_, err := reg.Execute(context.Background(), "slow", nil)
+	if !errors.Is(err, ErrToolTimeout) || !strings.Contains(err.Error(), "slow") {
+		t.Errorf("Execute error = %v, want ErrToolTimeout naming the tool", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Execute returned after %s, want the tool's timeout", elapsed)
+	}
+
+	fast := NewToolRegistry()
+	if err := fast.Register(slowTool{delay: time.Millisecond, timeout: time.Second}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if result, err := fast.Execute(context.Background(), "slow", nil); err != nil || result != "done" {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+}
+
+func TestToolRegistryRecoversPanics(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "panics"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		panic("boom")
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	_, err := reg.Execute(context.Background(), "panics", nil)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || !errors.Is(err, ErrToolPanicked) {
+		t.Fatalf("Execute error = %v, want a *PanicError", err)
+	}
+	if panicErr.Error() != "tool 'panics' panicked: boom" || panicErr.Value != "boom" || !strings.Contains(string(panicErr.Stack), "goroutine") {
+		t.Errorf("error = %q, value = %v, stack = %q", panicErr, panicErr.Value, panicErr.Stack)
+	}
+
+	// The model only sees the panic value, not the stack trace.
+	messages, err := ExecuteToolCalls(context.Background(), reg, []ToolCall{{ID: "1", Name: "panics"}})
+	if err != nil {
+		t.Fatalf("ExecuteToolCalls: %v", err)
+	}
+	if content := messages[0].Content; !strings.HasSuffix(content, "tool 'panics' panicked: boom") || strings.Contains(content, "goroutine") {
+		t.Errorf("content = %q", messages[0].Content)
+	}
+}
+
+// scriptedClient answers with the queued responses in order.
+type scriptedClient struct {
+	responses []ChatCompletionResponse
+}
+
+func (c *scriptedClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (ChatCompletionResponse, error) {
+	if len(c.responses) == 0 {
+		return ChatCompletionResponse{}, errors.New("no more responses")
+	}
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return resp, nil
+}
+
+func TestAgentRecoversToolPanics(t *testing.T) {
+	client := &scriptedClient{responses: []ChatCompletionResponse{{
+		Choices: []Choice{{
+			Message:      Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Name: "panics"}}},
+			FinishReason: FinishReasonToolCalls,
+		}},
+	}}}
+	agent, err := NewAgentBuilder().
+		SetClient(client).
+		SetName("agent").
+		SetMemory(NewSimpleMemory()).
+		AddTool(NewToolFunc(ToolDefinition{Name: "panics"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			panic("boom")
+		})).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if _, err := agent.Process(context.Background(), "user", "hi"); !errors.Is(err, ErrToolPanicked) || !strings.Contains(err.Error(), "tool 'panics' panicked: boom") {
+		t.Errorf("Process error = %v, want the recovered panic", err)
+	}
+}
+
+func TestToolFunc(t *testing.T) {
+	greeting := "hello"
+	tool := NewToolFunc(ToolDefinition{Name: "greet", Description: "Says hello."}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return greeting + " " + string(args), nil
+	})
+	reg := NewToolRegistry()
+	if err := reg.Register(tool); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if result, err := reg.Execute(context.Background(), "greet", json.RawMessage(`"world"`)); err != nil || result != `hello "world"` {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+	if _, err := (&ToolFunc{Def: ToolDefinition{Name: "empty"}}).Execute(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "has no function") {
+		t.Errorf("Execute error = %v, want the missing function", err)
+	}
+}
+
+// readOnlyTool declares itself free of side effects.
+type readOnlyTool struct {
+	*ToolFunc
+}
+
+func (readOnlyTool) ReadOnly() bool {
+	return true
+}
+
+func TestToolRegistryReadOnly(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(readOnlyTool{NewToolFunc(ToolDefinition{Name: "lookup"}, nil)}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(echoTool("write")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !reg.ReadOnly("lookup") || reg.ReadOnly("write") || reg.ReadOnly("missing") {
+		t.Error("ReadOnly does not follow ReadOnlyTool")
+	}
+}
+
+func TestToolRegistryValidate(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "ok", Description: "Fine.", Parameters: json.RawMessage(`{"type":"object","properties":{}}`)}, nil)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, def := range []ToolDefinition{
+		{Name: "undocumented", Parameters: json.RawMessage(`{"type":"object","properties":{}}`)},
+		{Name: "unparsed", Description: "Broken.", Parameters: json.RawMessage(`{`)},
+		{Name: "invalid", Description: "Broken.", Parameters: json.RawMessage(`{"type":"array"}`)},
+		{Name: "bare", Description: "No schema."},
+	} {
+		if err := reg.Register(NewToolFunc(def, nil)); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	err := reg.Validate()
+	for _, name := range []string{"undocumented", "unparsed", "invalid", "bare"} {
+		if err == nil || !strings.Contains(err.Error(), "tool "+name+" ") {
+			t.Errorf("Validate error = %v, want it to report %s", err, name)
+		}
+	}
+}
+
+// imageResult returns multi-part content.
+type imageResult struct{}
+
+func (imageResult) ContentParts() []ContentPart {
+	return []ContentPart{{Type: ContentPartText, Text: "a cat"}, {Type: ContentPartImageURL, ImageURL: "https://example.com/cat.png"}}
+}
+
+func TestExecuteToolCallsContentParts(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "photo"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return imageResult{}, nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	messages, err := ExecuteToolCalls(context.Background(), reg, []ToolCall{{ID: "1", Name: "photo"}})
+	if err != nil {
+		t.Fatalf("ExecuteToolCalls: %v", err)
+	}
+	if len(messages[0].Parts) != 2 || messages[0].Content != "" || messages[0].Parts[1].ImageURL != "https://example.com/cat.png" {
+		t.Errorf("message = %+v", messages[0])
+	}
+}
+
+func TestToolRegistryNotFound(t *testing.T) {
+	reg := NewToolRegistry()
+	_, err := reg.Execute(context.Background(), "missing", nil)
+	if !errors.Is(err, ErrToolNotFound) || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Execute error = %v, want ErrToolNotFound naming the tool", err)
+	}
+}
+
+func TestToolCallAccumulator(t *testing.T) {
+	index := func(i int) *int { return &i }
+	acc := NewToolCallAccumulator()
+	for _, delta := range []openai.ToolCall{
+		{Index: index(0), ID: "a", Function: openai.FunctionCall{Name: "weather", Arguments: `{"ci`}},
+		{Index: index(0), Function: openai.FunctionCall{Arguments: `ty":"Paris"}`}},
+		{Index: index(1), ID: "b", Function: openai.FunctionCall{Name: "time"}},
+		{Function: openai.FunctionCall{Arguments: `{}`}},
+	} {
+		acc.Add(delta)
+	}
+	calls := acc.Finish()
+	if len(calls) != 2 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if calls[0].ID != "a" || calls[0].Function.Arguments != `{"city":"Paris"}` || calls[0].Index != nil {
+		t.Errorf("first call = %+v", calls[0])
+	}
+	if calls[1].ID != "b" || calls[1].Function.Arguments != `{}` || calls[1].Type != openai.ToolTypeFunction {
+		t.Errorf("second call = %+v", calls[1])
+	}
+}
+
+// greeter is a SimpleTool.
+type greeter struct{}
+
+func (greeter) GetDefinition() ToolDefinition {
+	return ToolDefinition{Name: "greet", Description: "Says hello."}
+}
+
+func (greeter) ExecuteSimple(args json.RawMessage) any {
+	return "hello"
+}
+
+func TestNewSimpleTool(t *testing.T) {
+	reg := NewToolRegistry()
+	if err := reg.Register(NewSimpleTool(greeter{})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if result, err := reg.Execute(context.Background(), "greet", nil); err != nil || result != "hello" {
+		t.Errorf("Execute = %v, %v", result, err)
+	}
+}
+
+func TestToolRegistryTruncation(t *testing.T) {
+	long := strings.Repeat("é", 50)
+	reg := NewToolRegistry()
+	for _, tool := range []Tool{
+		NewToolFunc(ToolDefinition{Name: "long"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			return long, nil
+		}),
+		NewToolFunc(ToolDefinition{Name: "short"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			return "ok", nil
+		}),
+		NewToolFunc(ToolDefinition{Name: "parts"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+			return imageResult{}, nil
+		}),
+	} {
+		if err := reg.Register(tool); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	for _, limit := range []int{1, 2, 3, 13, 14, 15, 20, 40} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			reg.SetMaxResultBytes(limit)
+			result, err := reg.Execute(context.Background(), "long", nil)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			truncated, ok := result.(TruncatedResult)
+			if !ok {
+				t.Fatalf("result = %T, want TruncatedResult", result)
+			}
+			content := truncated.ResultString()
+			if len(content) > limit || !utf8.ValidString(content) {
+				t.Errorf("content %q has %d bytes for a limit of %d", content, len(content), limit)
+			}
+			if limit >= len(TruncationMarker) && !strings.HasSuffix(content, TruncationMarker) {
+				t.Errorf("content %q lacks the marker", content)
+			}
+		})
+	}
+
+	reg.SetMaxResultBytes(10)
+	if result, err := reg.Execute(context.Background(), "short", nil); err != nil || result != "ok" {
+		t.Errorf("Execute = %v, %v, want the result unchanged", result, err)
+	}
+	if result, err := reg.Execute(context.Background(), "parts", nil); err != nil || result != (imageResult{}) {
+		t.Errorf("Execute = %v, %v, want multi-part results untouched", result, err)
+	}
+}
+
+func TestNoParamsDispatch(t *testing.T) {
+	params, err := json.Marshal(NoParams())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	reg := NewToolRegistry()
+	reg.SetArgsValidation(true)
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "now", Parameters: params}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return "noon", nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	for _, args := range []string{``, `{}`} {
+		if result, err := reg.Execute(context.Background(), "now", json.RawMessage(args)); err != nil || result != "noon" {
+			t.Errorf("Execute(%q) = %v, %v", args, result, err)
+		}
+	}
+	if _, err := reg.Execute(context.Background(), "now", json.RawMessage(`{"extra":1}`)); err == nil {
+		t.Error("expected an error for an unexpected argument")
+	}
+}
+
+func TestToolRegistrySnapshot(t *testing.T) {
+	reg := NewToolRegistry()
+	params, err := GenerateRawSchema(struct {
+		City string `json:"city"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateRawSchema: %v", err)
+	}
+	weather := NewToolFunc(ToolDefinition{Name: "weather", Description: "Current weather.", Parameters: params}, nil)
+	if err := reg.Register(readOnlyTool{weather}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "broken", Parameters: json.RawMessage(`{`)}, nil)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	snapshot := reg.Snapshot()
+	if len(snapshot) != 2 || !snapshot[0].ReadOnly || snapshot[0].Parameters.Properties["city"].Type != String {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+	if snapshot[1].ReadOnly || snapshot[1].Parameters != nil {
+		t.Errorf("broken tool = %+v", snapshot[1])
+	}
+	data, err := json.Marshal(snapshot[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	assertJSON(t, json.RawMessage(data), `{"name":"weather","description":"Current weather.","readOnly":true,
+		"parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"],"additionalProperties":false}}`)
+}
+
+// closingReader records whether it was closed.
+type closingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestToolRegistryReaderResults(t *testing.T) {
+	reader := &closingReader{Reader: strings.NewReader(strings.Repeat("x", 100))}
+	reg := NewToolRegistry()
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "report"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return reader, nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(NewToolFunc(ToolDefinition{Name: "plain"}, func(ctx context.Context, args json.RawMessage) (any, error) {
+		return strings.NewReader("line one\nline two"), nil
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	messages, err := ExecuteToolCalls(context.Background(), reg, []ToolCall{{ID: "1", Name: "report"}, {ID: "2", Name: "plain"}})
+	if err != nil {
+		t.Fatalf("ExecuteToolCalls: %v", err)
+	}
+	if messages[0].Content != strings.Repeat("x", 100) || !reader.closed {
+		t.Errorf("content = %q, closed = %t", messages[0].Content, reader.closed)
+	}
+	if messages[1].Content != "line one\nline two" {
+		t.Errorf("content = %q, want the reader's text verbatim", messages[1].Content)
+	}
+
+	reader = &closingReader{Reader: strings.NewReader(strings.Repeat("x", 100))}
+	reg.SetMaxResultBytes(20)
+	result, err := reg.Execute(context.Background(), "report", nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := string(result.(TruncatedResult)); got != strings.Repeat("x", 20-len(TruncationMarker))+TruncationMarker || !reader.closed {
+		t.Errorf("result = %q, closed = %t", got, reader.closed)
+	}
+}
+
+func TestDecodeArgs(t *testing.T) {
+	type address struct {
+		Zip int `json:"zip"`
+	}
+	type args struct {
+		Address address  `json:"address"`
+		Tags    []string `json:"tags"`
+		Active  bool     `json:"active"`
+	}
+	tests := []struct {
+		name    string
+		args    string
+		strict  bool
+		wantErr string
+	}{
+		{name: "valid", args: `{"address":{"zip":1000},"tags":["a"]}`},
+		{name: "empty", args: ``},
+		{name: "null", args: `null`},
+		{name: "nested type", args: `{"address":{"zip":"1000"}}`, wantErr: "invalid argument 'address.zip': expected integer, got string"},
+		{name: "array element", args: `{"tags":["a",2]}`, wantErr: "invalid argument 'tags[1]': expected string, got number"},
+		{name: "boolean", args: `{"active":"yes"}`, wantErr: "invalid argument 'active': expected boolean, got string"},
+		{name: "malformed", args: `{"tags":`, wantErr: "malformed JSON"},
+		{name: "unknown field ignored", args: `{"extra":1}`},
+		{name: "unknown field rejected", args: `{"extra":1}`, strict: true, wantErr: "invalid argument 'extra': unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decode := DecodeArgs[args]
+			if tt.strict {
+				decode = DecodeArgsStrict[args]
+			}
+			_, err := decode(json.RawMessage(tt.args))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("DecodeArgs: %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DecodeArgs error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	transient := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		attempts  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "fails twice then succeeds", failures: 2, err: transient, attempts: 3, wantCalls: 3},
+		{name: "gives up", failures: 5, err: transient, attempts: 3, wantCalls: 3, wantErr: true},
+		{name: "invalid arguments are not retried", failures: 5, err: &ValidationError{Path: "city", Message: "required"}, attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "panics are not retried", failures: 5, err: &PanicError{Tool: "flaky", Value: "boom"}, attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "cancellation is not retried", failures: 5, err: fmt.Errorf("query: %w", context.Canceled), attempts: 3, wantCalls: 1, wantErr: true},
+		{name: "deadlines are not retried", failures: 5, err: context.DeadlineExceeded, attempts: 3, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := RetryMiddleware(tt.attempts, func(int) time.Duration { return time.Millisecond }, nil)(
+				func(ctx context.Context, name string, args json.RawMessage) (any, error) {
+					calls++
+					if calls <= tt.failures {
+						return nil, tt.err
+					}
+					return "ok", nil
+				})
+			result, err := handler(context.Background(), "flaky", nil)
+			if (err != nil) != tt.wantErr || calls != tt.wantCalls {
+				t.Errorf("result = %v, err = %v after %d calls, want %d calls", result, err, calls, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("unknown tools are not retried", func(t *testing.T) {
+		reg := NewToolRegistry()
+		reg.Use(RetryMiddleware(3, nil, nil))
+		if _, err := reg.Execute(context.Background(), "missing", nil); !errors.Is(err, ErrToolNotFound) {
+			t.Errorf("Execute error = %v, want ErrToolNotFound", err)
+		}
+	})
+
+	t.Run("context ends while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		handler := RetryMiddleware(3, func(int) time.Duration { return time.Hour }, nil)(
+			func(ctx context.Context, name string, args json.RawMessage) (any, error) {
+				return nil, transient
+			})
+		if _, err := handler(ctx, "flaky", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
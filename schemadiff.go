@@ -0,0 +1,119 @@
+package syndicate
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaChangeKind classifies a difference between two versions of a schema.
+type SchemaChangeKind string
+
+// Kinds of schema changes reported by DiffDefinitions.
+const (
+	PropertyAdded   SchemaChangeKind = "property_added"   // A property exists only in the new schema.
+	PropertyRemoved SchemaChangeKind = "property_removed" // A property exists only in the old schema.
+	TypeChanged     SchemaChangeKind = "type_changed"     // The type, or its nullability, differs.
+	RequiredAdded   SchemaChangeKind = "required_added"   // A property became required.
+	RequiredRemoved SchemaChangeKind = "required_removed" // A property is no longer required.
+)
+
+// SchemaChange describes one difference between two versions of a schema.
+type SchemaChange struct {
+	Kind SchemaChangeKind // What changed.
+	Path string           // Location of the affected property, e.g. "address.city" or "tags[]"; empty for the root.
+	Old  string           // Previous type, set for TypeChanged.
+	New  string           // New type, set for TypeChanged.
+}
+
+// String returns a human readable description of the change.
+func (c SchemaChange) String() string {
+	path := c.Path
+	if path == "" {
+		path = "<root>"
+	}
+	if c.Kind == TypeChanged {
+		return fmt.Sprintf("%s: %s from %s to %s", path, c.Kind, c.Old, c.New)
+	}
+	return fmt.Sprintf("%s: %s", path, c.Kind)
+}
+
+// DiffDefinitions compares two versions of a schema, such as the parameters of a tool before and after
+// an upgrade, and returns the added and removed properties, type changes and changes to required sets.
+// Object properties, array items and map values are compared recursively; changes are ordered by path.
+func DiffDefinitions(old, new *Definition) []SchemaChange {
+	var changes []SchemaChange
+	diffDefinitions(old, new, "", &changes)
+	return changes
+}
+
+// diffDefinitions appends the differences between old and new at path to changes.
+func diffDefinitions(old, new *Definition, path string, changes *[]SchemaChange) {
+	if old == nil || new == nil {
+		return
+	}
+	if oldType, newType := describeSchemaType(old), describeSchemaType(new); oldType != newType {
+		*changes = append(*changes, SchemaChange{Kind: TypeChanged, Path: path, Old: oldType, New: newType})
+		return
+	}
+
+	names := make(map[string]bool, len(old.Properties)+len(new.Properties))
+	for name := range old.Properties {
+		names[name] = true
+	}
+	for name := range new.Properties {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	oldRequired, newRequired := stringSet(old.Required), stringSet(new.Required)
+	for _, name := range sorted {
+		propPath := joinPath(path, name)
+		oldProp, inOld := old.Properties[name]
+		newProp, inNew := new.Properties[name]
+		switch {
+		case !inOld:
+			*changes = append(*changes, SchemaChange{Kind: PropertyAdded, Path: propPath})
+		case !inNew:
+			*changes = append(*changes, SchemaChange{Kind: PropertyRemoved, Path: propPath})
+		default:
+			if newRequired[name] && !oldRequired[name] {
+				*changes = append(*changes, SchemaChange{Kind: RequiredAdded, Path: propPath})
+			} else if oldRequired[name] && !newRequired[name] {
+				*changes = append(*changes, SchemaChange{Kind: RequiredRemoved, Path: propPath})
+			}
+			diffDefinitions(&oldProp, &newProp, propPath, changes)
+		}
+	}
+
+	diffDefinitions(old.Items, new.Items, path+"[]", changes)
+	if old.describesMap() && new.describesMap() {
+		diffDefinitions(old.AdditionalProperties.Schema, new.AdditionalProperties.Schema, joinPath(path, "*"), changes)
+	}
+}
+
+// describeSchemaType returns the type of def as written in a schema, such as "string" or "string|null",
+// falling back to the reference of $ref definitions.
+func describeSchemaType(def *Definition) string {
+	switch {
+	case def.Type == "" && def.Ref != "":
+		return def.Ref
+	case def.Type == "":
+		return "any"
+	case def.Nullable && def.Type != Null:
+		return string(def.Type) + "|null"
+	}
+	return string(def.Type)
+}
+
+// stringSet returns a set holding the given values.
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,80 @@
+package syndicate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// AnalyzeOptionality reports optional fields of v whose zero value is indistinguishable from an omitted one.
+// A non-pointer bool or numeric field that the schema marks optional decodes a missing value as false or 0,
+// so a tool cannot tell an omitted argument from an explicit zero; a pointer type avoids the ambiguity.
+// Nested structs are inspected as well. Each finding names the field by its dotted Go path, such as
+// "Order.Filters.InStock". This is a diagnostic helper and does not affect generated schemas.
+func AnalyzeOptionality(v any) []string {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var findings []string
+	analyzeOptionality(t, schemaTypeName(t), make(map[reflect.Type]bool), &findings)
+	return findings
+}
+
+// analyzeOptionality walks t, appending a finding for every ambiguous optional field under path.
+func analyzeOptionality(t reflect.Type, path string, seen map[reflect.Type]bool, findings *[]string) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := joinPath(path, field.Name)
+		if embedded, ok := embeddedStructType(field, "json"); ok {
+			analyzeOptionality(embedded, fieldPath, seen, findings)
+			continue
+		}
+		if !field.IsExported() || field.Tag.Get("json") == "-" || field.Tag.Get("schema") == "-" {
+			continue
+		}
+		if optionalZeroValue(field) {
+			*findings = append(*findings, fmt.Sprintf(
+				"%s: optional %s field cannot distinguish its zero value from an omitted one; use *%s",
+				fieldPath, field.Type.Kind(), field.Type.String()))
+		}
+		analyzeOptionality(field.Type, fieldPath, seen, findings)
+	}
+}
+
+// optionalZeroValue reports whether field is a non-pointer bool or numeric field that the schema marks optional.
+func optionalZeroValue(field reflect.StructField) bool {
+	switch field.Type.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return false
+	}
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	omitEmpty := false
+	for _, opt := range strings.Split(options, ",") {
+		if strings.TrimSpace(opt) == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	// Mirror processField: omitempty makes a field optional unless the required tag says otherwise.
+	if required, err := strconv.ParseBool(field.Tag.Get("required")); err == nil {
+		return !required
+	}
+	return omitEmpty
+}
@@ -0,0 +1,63 @@
+package syndicate
+
+import (
+	"slices"
+)
+
+// PropertyPath describes a leaf value of a schema, as listed by Paths.
+type PropertyPath struct {
+	Path     string   // Dotted location, e.g. "address.city", "tags[]" or "orders[].id".
+	Type     DataType // Type of the value; empty for untyped values.
+	Nullable bool     // Whether null is accepted as well.
+	Required bool     // Whether the enclosing object requires the property holding the value.
+	Ref      string   // Reference of a recursive definition that is not expanded again.
+}
+
+// Paths returns the leaf values of d in depth-first order, such as form fields built from a schema.
+// Nested object properties are expanded and not listed themselves; array items are addressed with "[]"
+// and map values with "*", following DiffDefinitions. Local $ref targets under $defs or definitions are
+// expanded in place, except for a reference back to a definition already being expanded, which is listed
+// once with its Ref so recursive types terminate. Required reflects the innermost object only.
+func (d *Definition) Paths() []PropertyPath {
+	if d == nil {
+		return nil
+	}
+	w := pathWalker{root: d}
+	w.walk(d, "", false, nil)
+	return w.paths
+}
+
+// pathWalker collects the leaf paths of a schema.
+type pathWalker struct {
+	root  *Definition    // Schema whose $defs and definitions resolve $ref.
+	paths []PropertyPath // Leaves found so far.
+}
+
+// walk lists the leaves of def at path. required is the flag of the property holding def, and stack holds
+// the references being expanded.
+func (w *pathWalker) walk(def *Definition, path string, required bool, stack []string) {
+	if def.Ref != "" {
+		target, ok := lookupRef(w.root, def.Ref)
+		if !ok || slices.Contains(stack, def.Ref) {
+			w.paths = append(w.paths, PropertyPath{Path: path, Type: target.Type, Nullable: def.Nullable, Required: required, Ref: def.Ref})
+			return
+		}
+		w.walk(target, path, required, append(stack, def.Ref))
+		return
+	}
+
+	switch {
+	case len(def.Properties) > 0:
+		requiredSet := stringSet(def.Required)
+		for _, name := range propertyNames(def) {
+			prop := def.Properties[name]
+			w.walk(&prop, joinPath(path, name), requiredSet[name], stack)
+		}
+	case def.Type == Array && def.Items != nil:
+		w.walk(def.Items, path+"[]", required, stack)
+	case def.describesMap():
+		w.walk(def.AdditionalProperties.Schema, joinPath(path, "*"), required, stack)
+	default:
+		w.paths = append(w.paths, PropertyPath{Path: path, Type: def.Type, Nullable: def.Nullable, Required: required})
+	}
+}
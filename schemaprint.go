@@ -0,0 +1,85 @@
+package syndicate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// String returns the definition as indented JSON, for debugging generated schemas.
+// It does not affect how the definition is marshalled.
+func (d *Definition) String() string {
+	if d == nil {
+		return "<nil>"
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("<invalid definition: %v>", err)
+	}
+	return string(data)
+}
+
+// Tree returns an ASCII tree of the properties of d with their types, marking required properties,
+// for a quick overview of a generated schema. Properties follow their x-order when recorded and are
+// sorted otherwise; the properties of array items and map values are listed under their property.
+//
+//	object
+//	├── address: object (required)
+//	│   └── city: string (required)
+//	└── tags: array of string
+func (d *Definition) Tree() string {
+	if d == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	b.WriteString(treeType(d))
+	b.WriteString("\n")
+	writeTree(&b, d, "")
+	return b.String()
+}
+
+// writeTree writes a line for every property of def, each prefixed by prefix and its branch.
+func writeTree(b *strings.Builder, def *Definition, prefix string) {
+	def = treeObject(def)
+	required := stringSet(def.Required)
+	names := propertyNames(def)
+	for i, name := range names {
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+		prop := def.Properties[name]
+		fmt.Fprintf(b, "%s%s%s: %s", prefix, branch, name, treeType(&prop))
+		if required[name] {
+			b.WriteString(" (required)")
+		}
+		b.WriteString("\n")
+		writeTree(b, &prop, prefix+indent)
+	}
+}
+
+// treeObject returns the object schema whose properties are listed under def, looking through array
+// items and map values.
+func treeObject(def *Definition) *Definition {
+	for {
+		switch {
+		case def.Type == Array && def.Items != nil:
+			def = def.Items
+		case len(def.Properties) == 0 && def.describesMap():
+			def = def.AdditionalProperties.Schema
+		default:
+			return def
+		}
+	}
+}
+
+// treeType describes the type of def on a tree line, such as "string", "array of integer" or "map of string".
+func treeType(def *Definition) string {
+	switch {
+	case def.Type == Array && def.Items != nil:
+		return describeSchemaType(def) + " of " + treeType(def.Items)
+	case def.Type == Object && len(def.Properties) == 0 && def.describesMap():
+		return "map of " + treeType(def.AdditionalProperties.Schema)
+	}
+	return describeSchemaType(def)
+}
@@ -0,0 +1,82 @@
+package syndicate
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// registeredEnum holds the values registered for a named type through RegisterEnum.
+type registeredEnum struct {
+	values []any
+	names  []string
+}
+
+var (
+	enumRegistry      = make(map[reflect.Type]registeredEnum)
+	enumRegistryMutex sync.RWMutex
+)
+
+// RegisterEnum associates a set of allowed values with a named type, such as a typed integer
+// constant (type Status int), since reflection cannot recover constant declarations.
+// Schemas generated for t include the values under enum and, when names are given, their
+// constant names under the x-enum-varnames extension. names must be empty or match values in length.
+// An enum tag on a field still overrides the registered values.
+func RegisterEnum(t reflect.Type, values []any, names []string) error {
+	if t == nil {
+		return fmt.Errorf("cannot register enum for nil type")
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("enum for %s must have at least one value", t.String())
+	}
+	if len(names) > 0 && len(names) != len(values) {
+		return fmt.Errorf("enum for %s has %d values but %d names", t.String(), len(values), len(names))
+	}
+
+	enumRegistryMutex.Lock()
+	defer enumRegistryMutex.Unlock()
+	enumRegistry[t] = registeredEnum{
+		values: append([]any(nil), values...),
+		names:  append([]string(nil), names...),
+	}
+	return nil
+}
+
+// lookupEnum returns the enum registered for t, if any.
+func lookupEnum(t reflect.Type) (registeredEnum, bool) {
+	enumRegistryMutex.RLock()
+	defer enumRegistryMutex.RUnlock()
+	enum, ok := enumRegistry[t]
+	return enum, ok
+}
+
+var (
+	formatRegistry      = make(map[reflect.Type]string)
+	formatRegistryMutex sync.RWMutex
+)
+
+// RegisterFormat associates a default format hint with a named type, such as type Email string,
+// so every field of that type carries the format without repeating a tag.
+// Schemas generated for t include the format under format; a format tag on a field still
+// overrides the registered value.
+func RegisterFormat(t reflect.Type, format string) error {
+	if t == nil {
+		return fmt.Errorf("cannot register format for nil type")
+	}
+	if format == "" {
+		return fmt.Errorf("format for %s must not be empty", t.String())
+	}
+
+	formatRegistryMutex.Lock()
+	defer formatRegistryMutex.Unlock()
+	formatRegistry[t] = format
+	return nil
+}
+
+// lookupFormat returns the format registered for t, if any.
+func lookupFormat(t reflect.Type) (string, bool) {
+	formatRegistryMutex.RLock()
+	defer formatRegistryMutex.RUnlock()
+	format, ok := formatRegistry[t]
+	return format, ok
+}
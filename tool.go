@@ -1,11 +1,20 @@
 package syndicate
 
 import (
+	"context"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DataType represents a JSON data type in the generated schema.
@@ -25,33 +34,725 @@ const (
 // Definition is a struct for describing a JSON Schema.
 // It includes type, description, enumeration values, properties, required fields, and additional items.
 type Definition struct {
+	Schema               string                `json:"$schema,omitempty"` // Draft URL, set on standalone documents.
 	Type                 DataType              `json:"type,omitempty"`
+	Title                string                `json:"title,omitempty"`
 	Description          string                `json:"description,omitempty"`
-	Enum                 []string              `json:"enum,omitempty"`
+	Descriptions         map[string]string     `json:"x-descriptions,omitempty"` // Localized descriptions keyed by language.
+	Comment              string                `json:"$comment,omitempty"`       // Note for schema maintainers; ignored by validation.
+	Format               string                `json:"format,omitempty"`
+	ContentEncoding      string                `json:"contentEncoding,omitempty"`
+	ContentMediaType     string                `json:"contentMediaType,omitempty"`
+	Enum                 []any                 `json:"enum,omitempty"`
+	EnumVarNames         []string              `json:"x-enum-varnames,omitempty"`
+	EnumDescriptions     []string              `json:"x-enumDescriptions,omitempty"`
+	Minimum              *float64              `json:"minimum,omitempty"`
+	Maximum              *float64              `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64              `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64              `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64              `json:"multipleOf,omitempty"`
+	MinLength            *int                  `json:"minLength,omitempty"`
+	MaxLength            *int                  `json:"maxLength,omitempty"`
+	Pattern              string                `json:"pattern,omitempty"`
 	Properties           map[string]Definition `json:"properties,omitempty"`
 	Required             []string              `json:"required,omitempty"`
 	Items                *Definition           `json:"items,omitempty"`
-	AdditionalProperties any                   `json:"additionalProperties,omitempty"`
+	MinItems             *int                  `json:"minItems,omitempty"`
+	MaxItems             *int                  `json:"maxItems,omitempty"`
+	UniqueItems          bool                  `json:"uniqueItems,omitempty"`
+	AdditionalProperties *AdditionalProps      `json:"additionalProperties,omitempty"`
+	Default              any                   `json:"default,omitempty"`
+	Const                any                   `json:"const,omitempty"`
+	Examples             []any                 `json:"examples,omitempty"`
+	Deprecated           bool                  `json:"deprecated,omitempty"`
+	ReadOnly             bool                  `json:"readOnly,omitempty"`
+	WriteOnly            bool                  `json:"writeOnly,omitempty"`
+	OneOf                []Definition          `json:"oneOf,omitempty"`
+	AnyOf                []Definition          `json:"anyOf,omitempty"`
+	AllOf                []Definition          `json:"allOf,omitempty"`
+	If                   *Definition           `json:"if,omitempty"`
+	Then                 *Definition           `json:"then,omitempty"` // Applies when If matches.
+	Else                 *Definition           `json:"else,omitempty"` // Applies when If does not match.
+	Ref                  string                `json:"$ref,omitempty"`
+	Defs                 map[string]Definition `json:"$defs,omitempty"`
+	Definitions          map[string]Definition `json:"definitions,omitempty"` // Draft-07 counterpart of Defs.
+	PropertyOrder        []string              `json:"x-order,omitempty"`     // Declaration order of Properties.
+	Nullable             bool                  `json:"-"`                     // When set, the type is emitted as a union with "null".
+	Raw                  map[string]any        `json:"-"`                     // Extra keywords merged into the output; modeled keywords win.
 }
 
 // MarshalJSON provides custom JSON marshalling for the Definition type.
-// It ensures that the Properties map is initialized before marshalling.
-func (d *Definition) MarshalJSON() ([]byte, error) {
-	if d.Properties == nil {
-		d.Properties = make(map[string]Definition)
+// Object definitions always carry a properties map, even when empty, untyped definitions such as the
+// subschemas of if/then/else keep theirs when set, other types never do, and nullable
+// definitions are emitted with a type union such as ["string", "null"]. additionalProperties follows
+// the same rule, so it never leaks onto a string or array definition.
+// Keywords in Raw are appended after the modeled ones, skipping any keyword the definition already emits.
+// The receiver is never modified, so a shared Definition can be marshalled concurrently,
+// and the value receiver ensures definitions nested in Properties are marshalled the same way.
+func (d Definition) MarshalJSON() ([]byte, error) {
+	var typ any
+	if d.Type != "" {
+		typ = d.Type
+		if d.Nullable && d.Type != Null {
+			typ = []DataType{d.Type, Null}
+		}
+	}
+	// Properties are only meaningful for objects; strict validators reject them elsewhere.
+	// Maps are described by an additionalProperties schema alone, so they get no empty properties map.
+	var properties any
+	if d.Type == Object {
+		if len(d.Properties) > 0 {
+			properties = d.Properties
+		} else if !d.describesMap() {
+			properties = map[string]Definition{}
+		}
+	} else if d.Type == "" && len(d.Properties) > 0 {
+		properties = d.Properties
+	} else if d.Type != "" {
+		d.AdditionalProperties = nil
 	}
+	d.Properties = nil
 	type Alias Definition
-	return json.Marshal(struct {
+	data, err := json.Marshal(struct {
+		Schema     string `json:"$schema,omitempty"`
+		Type       any    `json:"type,omitempty"`
+		Properties any    `json:"properties,omitempty"`
 		Alias
 	}{
-		Alias: (Alias)(*d),
+		Schema:     d.Schema,
+		Type:       typ,
+		Properties: properties,
+		Alias:      (Alias)(d),
 	})
+	if err != nil || len(d.Raw) == 0 {
+		return data, err
+	}
+	return appendRawKeywords(data, d.Raw)
+}
+
+// appendRawKeywords appends the keywords of raw that are not already present to the JSON object data,
+// in sorted order so the output is deterministic.
+func appendRawKeywords(data []byte, raw map[string]any) ([]byte, error) {
+	var emitted map[string]json.RawMessage
+	if err := json.Unmarshal(data, &emitted); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		if _, exists := emitted[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return data, nil
+	}
+	sort.Strings(keys)
+
+	out := append([]byte(nil), data[:len(data)-1]...)
+	for i, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(raw[key])
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling raw keyword '%s': %w", key, err)
+		}
+		if i > 0 || len(emitted) > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, name...)
+		out = append(out, ':')
+		out = append(out, value...)
+	}
+	return append(out, '}'), nil
+}
+
+// describesMap reports whether the definition constrains its values through an
+// additionalProperties schema, as generated for Go map types, rather than a boolean.
+func (d Definition) describesMap() bool {
+	return d.AdditionalProperties != nil && d.AdditionalProperties.Schema != nil
+}
+
+// AdditionalProps is the value of the additionalProperties keyword: either a boolean allowing or
+// forbidding undeclared properties, or a schema their values must match. Only one of Bool and Schema
+// should be set; Schema takes precedence, and a value with neither set marshals as true.
+type AdditionalProps struct {
+	Bool   *bool       // Whether undeclared properties are allowed.
+	Schema *Definition // Schema of the values of undeclared properties, as generated for maps.
+}
+
+// AllowAdditionalProperties returns an AdditionalProps that allows or forbids undeclared properties.
+func AllowAdditionalProperties(allowed bool) *AdditionalProps {
+	return &AdditionalProps{Bool: &allowed}
+}
+
+// AdditionalPropertiesSchema returns an AdditionalProps requiring undeclared properties to match schema.
+func AdditionalPropertiesSchema(schema *Definition) *AdditionalProps {
+	return &AdditionalProps{Schema: schema}
+}
+
+// NewAdditionalProps converts a value of the former untyped AdditionalProperties field, which held nil,
+// a bool, a Definition or a *Definition, easing migration of code that built definitions by hand.
+func NewAdditionalProps(v any) (*AdditionalProps, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return AllowAdditionalProperties(value), nil
+	case Definition:
+		return AdditionalPropertiesSchema(&value), nil
+	case *Definition:
+		return AdditionalPropertiesSchema(value), nil
+	case *AdditionalProps:
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported type for AdditionalProperties: %T", v)
+}
+
+// forbidden reports whether a is the boolean false, forbidding undeclared properties.
+func (a *AdditionalProps) forbidden() bool {
+	return a != nil && a.Schema == nil && a.Bool != nil && !*a.Bool
+}
+
+// clone returns a deep copy of a.
+func (a *AdditionalProps) clone() *AdditionalProps {
+	if a == nil {
+		return nil
+	}
+	return &AdditionalProps{Bool: clonePointer(a.Bool), Schema: a.Schema.Clone()}
+}
+
+// MarshalJSON emits the schema when one is set, and the boolean otherwise.
+func (a AdditionalProps) MarshalJSON() ([]byte, error) {
+	switch {
+	case a.Schema != nil:
+		return json.Marshal(a.Schema)
+	case a.Bool != nil:
+		return json.Marshal(*a.Bool)
+	}
+	return []byte("true"), nil
+}
+
+// UnmarshalJSON decodes either a boolean or a schema.
+func (a *AdditionalProps) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		*a = AdditionalProps{Bool: &allowed}
+		return nil
+	}
+	var schema Definition
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("invalid additionalProperties: %w", err)
+	}
+	*a = AdditionalProps{Schema: &schema}
+	return nil
+}
+
+// UnmarshalJSON decodes a JSON Schema document into the Definition.
+// It accepts a type union with "null" (such as ["string", "null"]) as a nullable type,
+// decodes additionalProperties into either a boolean or a schema, and keeps keywords
+// without a dedicated field in Raw.
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	type Alias Definition
+	aux := struct {
+		Type json.RawMessage `json:"type,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(d),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	d.Type, d.Nullable = "", false
+	if len(aux.Type) > 0 {
+		var single DataType
+		var union []DataType
+		if err := json.Unmarshal(aux.Type, &single); err == nil {
+			d.Type = single
+		} else if err := json.Unmarshal(aux.Type, &union); err == nil {
+			for _, t := range union {
+				switch {
+				case t == Null && len(union) > 1:
+					d.Nullable = true
+				case d.Type == "":
+					d.Type = t
+				default:
+					return fmt.Errorf("unsupported type union %s", string(aux.Type))
+				}
+			}
+		} else {
+			return fmt.Errorf("invalid type %s", string(aux.Type))
+		}
+	}
+
+	// Keep keywords the Definition does not model in Raw, so they survive a round trip.
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	d.Raw = nil
+	for key, value := range keywords {
+		if definitionKeywords[key] {
+			continue
+		}
+		var decoded any
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return fmt.Errorf("invalid keyword '%s': %w", key, err)
+		}
+		if d.Raw == nil {
+			d.Raw = make(map[string]any)
+		}
+		d.Raw[key] = decoded
+	}
+	return nil
+}
+
+// definitionKeywords holds the JSON keywords modeled by the fields of Definition.
+var definitionKeywords = func() map[string]bool {
+	keywords := make(map[string]bool)
+	t := reflect.TypeOf(Definition{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keywords[name] = true
+		}
+	}
+	return keywords
+}()
+
+// ParseDefinition decodes a JSON Schema document, such as a function schema received from a remote
+// registry, into a Definition that can be composed further or validated against.
+func ParseDefinition(data []byte) (*Definition, error) {
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("error parsing schema: %w", err)
+	}
+	return &def, nil
+}
+
+// CanonicalJSON returns a byte-for-byte stable encoding of d, suitable as a cache key for tool definitions.
+// Keywords are emitted in a fixed order, map keys are sorted and every required list is sorted, so
+// definitions that differ only in the order of their required properties encode identically.
+// Order-sensitive lists such as enum values and x-order are kept as they are.
+func (d *Definition) CanonicalJSON() ([]byte, error) {
+	if d == nil {
+		return nil, fmt.Errorf("cannot encode nil definition")
+	}
+	c := d.Clone()
+	sortRequired(c)
+	return json.Marshal(c)
+}
+
+// sortRequired recursively sorts the required lists of def and its subschemas in place.
+func sortRequired(def *Definition) {
+	sort.Strings(def.Required)
+	for _, defs := range []map[string]Definition{def.Properties, def.Defs, def.Definitions} {
+		for name, sub := range defs {
+			sortRequired(&sub)
+			defs[name] = sub
+		}
+	}
+	if def.Items != nil {
+		sortRequired(def.Items)
+	}
+	if def.describesMap() {
+		sortRequired(def.AdditionalProperties.Schema)
+	}
+	for _, subschemas := range [][]Definition{def.OneOf, def.AnyOf, def.AllOf} {
+		for i := range subschemas {
+			sortRequired(&subschemas[i])
+		}
+	}
+	for _, conditional := range []*Definition{def.If, def.Then, def.Else} {
+		if conditional != nil {
+			sortRequired(conditional)
+		}
+	}
+}
+
+// Clone returns a deep copy of the definition, so a shared or cached schema can be adjusted per call
+// without affecting other users. Nested definitions, slices, maps and JSON values held in Enum,
+// Default, Const and Examples are all copied.
+func (d *Definition) Clone() *Definition {
+	if d == nil {
+		return nil
+	}
+	c := *d
+	c.Enum = cloneJSONValues(d.Enum)
+	c.Descriptions = maps.Clone(d.Descriptions)
+	c.EnumVarNames = slices.Clone(d.EnumVarNames)
+	c.EnumDescriptions = slices.Clone(d.EnumDescriptions)
+	c.Minimum = clonePointer(d.Minimum)
+	c.Maximum = clonePointer(d.Maximum)
+	c.ExclusiveMinimum = clonePointer(d.ExclusiveMinimum)
+	c.ExclusiveMaximum = clonePointer(d.ExclusiveMaximum)
+	c.MultipleOf = clonePointer(d.MultipleOf)
+	c.MinLength = clonePointer(d.MinLength)
+	c.MaxLength = clonePointer(d.MaxLength)
+	c.MinItems = clonePointer(d.MinItems)
+	c.MaxItems = clonePointer(d.MaxItems)
+	c.Properties = cloneDefinitionMap(d.Properties)
+	c.Required = slices.Clone(d.Required)
+	c.Items = d.Items.Clone()
+	c.AdditionalProperties = d.AdditionalProperties.clone()
+	c.Default = cloneJSONValue(d.Default)
+	c.Const = cloneJSONValue(d.Const)
+	c.Examples = cloneJSONValues(d.Examples)
+	c.OneOf = cloneDefinitions(d.OneOf)
+	c.AnyOf = cloneDefinitions(d.AnyOf)
+	c.AllOf = cloneDefinitions(d.AllOf)
+	c.If = d.If.Clone()
+	c.Then = d.Then.Clone()
+	c.Else = d.Else.Clone()
+	c.Defs = cloneDefinitionMap(d.Defs)
+	c.Definitions = cloneDefinitionMap(d.Definitions)
+	c.PropertyOrder = slices.Clone(d.PropertyOrder)
+	if d.Raw != nil {
+		c.Raw = cloneJSONValue(d.Raw).(map[string]any)
+	}
+	return &c
+}
+
+// Merge returns a new Definition layering other onto d, such as a base schema extended with an extra argument
+// shared by every tool. Object properties and shared definitions are combined, erroring when both sides
+// define the same name differently; required and x-order lists are unioned; and for every other keyword
+// the value of d is kept when set, otherwise the value of other is used. Neither input is modified.
+func (d *Definition) Merge(other *Definition) (*Definition, error) {
+	merged := d.Clone()
+	if merged == nil {
+		merged = &Definition{}
+	}
+	if other == nil {
+		return merged, nil
+	}
+	o := other.Clone()
+	if merged.Type != "" && o.Type != "" && merged.Type != o.Type {
+		return nil, fmt.Errorf("cannot merge schemas of type '%s' and '%s'", merged.Type, o.Type)
+	}
+
+	var err error
+	if merged.Properties, err = mergeDefinitionMaps("property", merged.Properties, o.Properties); err != nil {
+		return nil, err
+	}
+	if merged.Defs, err = mergeDefinitionMaps("definition", merged.Defs, o.Defs); err != nil {
+		return nil, err
+	}
+	if merged.Definitions, err = mergeDefinitionMaps("definition", merged.Definitions, o.Definitions); err != nil {
+		return nil, err
+	}
+	merged.Required = unionStrings(merged.Required, o.Required)
+	merged.PropertyOrder = unionStrings(merged.PropertyOrder, o.PropertyOrder)
+	for key, value := range o.Raw {
+		if _, exists := merged.Raw[key]; !exists {
+			if merged.Raw == nil {
+				merged.Raw = make(map[string]any)
+			}
+			merged.Raw[key] = value
+		}
+	}
+
+	// Every remaining keyword falls back to the value of other when unset on d.
+	mv, ov := reflect.ValueOf(merged).Elem(), reflect.ValueOf(o).Elem()
+	for i := 0; i < mv.NumField(); i++ {
+		switch mv.Type().Field(i).Name {
+		case "Properties", "Defs", "Definitions", "Required", "PropertyOrder", "Raw":
+			continue
+		}
+		if mv.Field(i).IsZero() {
+			mv.Field(i).Set(ov.Field(i))
+		}
+	}
+	return merged, nil
+}
+
+// Resolve returns a copy of d with every local $ref replaced by the definition it points to, the inverse
+// of the references generated for recursive types. References of the form "#/$defs/Name" and
+// "#/definitions/Name" are looked up in defs, or in the $defs and definitions of d when defs is nil.
+// Keywords written next to a $ref, such as a description, take precedence over those of the target.
+// The result carries no shared definitions. Circular references cannot be inlined and return an error.
+func (d *Definition) Resolve(defs map[string]Definition) (*Definition, error) {
+	if d == nil {
+		return nil, nil
+	}
+	if defs == nil {
+		defs = make(map[string]Definition, len(d.Defs)+len(d.Definitions))
+		for name, def := range d.Definitions {
+			defs[name] = def
+		}
+		for name, def := range d.Defs {
+			defs[name] = def
+		}
+	}
+	resolved, err := resolveRefs(d, defs, nil)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Defs, resolved.Definitions = nil, nil
+	return resolved, nil
+}
+
+// lookupRef looks up a local reference of the form "#/$defs/Name" or "#/definitions/Name" under the $defs
+// or definitions of root. The returned definition is a copy that may be modified.
+func lookupRef(root *Definition, ref string) (*Definition, bool) {
+	var target Definition
+	var ok bool
+	if name, found := strings.CutPrefix(ref, "#/$defs/"); found {
+		target, ok = root.Defs[name]
+	} else if name, found := strings.CutPrefix(ref, "#/definitions/"); found {
+		target, ok = root.Definitions[name]
+	}
+	return &target, ok
+}
+
+// resolveRefs returns a copy of def with its references inlined. stack holds the names of the
+// definitions currently being inlined, so a reference back to one of them is reported as circular.
+func resolveRefs(def *Definition, defs map[string]Definition, stack []string) (*Definition, error) {
+	if def.Ref != "" {
+		// defs merges both keywords, so it serves references of either form.
+		target, ok := lookupRef(&Definition{Defs: defs, Definitions: defs}, def.Ref)
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", def.Ref)
+		}
+		name := def.Ref[strings.LastIndex(def.Ref, "/")+1:]
+		if slices.Contains(stack, name) {
+			return nil, fmt.Errorf("circular reference %s", def.Ref)
+		}
+		inlined, err := resolveRefs(target, defs, append(stack, name))
+		if err != nil {
+			return nil, err
+		}
+		siblings := def.Clone()
+		siblings.Ref = ""
+		return siblings.Merge(inlined)
+	}
+
+	c := def.Clone()
+	for name, prop := range c.Properties {
+		resolved, err := resolveRefs(&prop, defs, stack)
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", name, err)
+		}
+		c.Properties[name] = *resolved
+	}
+	if c.Items != nil {
+		resolved, err := resolveRefs(c.Items, defs, stack)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		c.Items = resolved
+	}
+	if c.describesMap() {
+		resolved, err := resolveRefs(c.AdditionalProperties.Schema, defs, stack)
+		if err != nil {
+			return nil, fmt.Errorf("additionalProperties: %w", err)
+		}
+		c.AdditionalProperties = AdditionalPropertiesSchema(resolved)
+	}
+	for _, subschemas := range [][]Definition{c.OneOf, c.AnyOf, c.AllOf} {
+		for i := range subschemas {
+			resolved, err := resolveRefs(&subschemas[i], defs, stack)
+			if err != nil {
+				return nil, err
+			}
+			subschemas[i] = *resolved
+		}
+	}
+	for _, conditional := range []**Definition{&c.If, &c.Then, &c.Else} {
+		if *conditional == nil {
+			continue
+		}
+		resolved, err := resolveRefs(*conditional, defs, stack)
+		if err != nil {
+			return nil, err
+		}
+		*conditional = resolved
+	}
+	return c, nil
+}
+
+// mergeDefinitionMaps combines two maps of definitions, erroring when a name is defined differently on each side.
+func mergeDefinitionMaps(kind string, base, extra map[string]Definition) (map[string]Definition, error) {
+	for name, def := range extra {
+		if existing, exists := base[name]; exists {
+			if !reflect.DeepEqual(existing, def) {
+				return nil, fmt.Errorf("conflicting definitions for %s '%s'", kind, name)
+			}
+			continue
+		}
+		if base == nil {
+			base = make(map[string]Definition, len(extra))
+		}
+		base[name] = def
+	}
+	return base, nil
+}
+
+// unionStrings appends the values of extra missing from base, preserving their order.
+func unionStrings(base, extra []string) []string {
+	for _, value := range extra {
+		if !slices.Contains(base, value) {
+			base = append(base, value)
+		}
+	}
+	return base
+}
+
+// clonePointer returns a pointer to a copy of the value p points to, or nil if p is nil.
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// cloneDefinitions deep-copies a slice of definitions, preserving nil.
+func cloneDefinitions(defs []Definition) []Definition {
+	if defs == nil {
+		return nil
+	}
+	cloned := make([]Definition, len(defs))
+	for i := range defs {
+		cloned[i] = *defs[i].Clone()
+	}
+	return cloned
+}
+
+// cloneDefinitionMap deep-copies a map of definitions, preserving nil.
+func cloneDefinitionMap(defs map[string]Definition) map[string]Definition {
+	if defs == nil {
+		return nil
+	}
+	cloned := make(map[string]Definition, len(defs))
+	for name, def := range defs {
+		cloned[name] = *def.Clone()
+	}
+	return cloned
+}
+
+// cloneJSONValues deep-copies a slice of JSON values, preserving nil.
+func cloneJSONValues(values []any) []any {
+	if values == nil {
+		return nil
+	}
+	cloned := make([]any, len(values))
+	for i, value := range values {
+		cloned[i] = cloneJSONValue(value)
+	}
+	return cloned
+}
+
+// cloneJSONValue deep-copies the arrays and objects of a decoded JSON value; other values are returned as is.
+func cloneJSONValue(value any) any {
+	switch v := value.(type) {
+	case []any:
+		return cloneJSONValues(v)
+	case map[string]any:
+		cloned := make(map[string]any, len(v))
+		for key, item := range v {
+			cloned[key] = cloneJSONValue(item)
+		}
+		return cloned
+	}
+	return value
+}
+
+// SchemaDraft identifies a JSON Schema draft whose keywords the generated schema follows.
+type SchemaDraft string
+
+// Supported JSON Schema drafts.
+const (
+	// Draft202012 collects shared definitions under "$defs".
+	Draft202012 SchemaDraft = "2020-12"
+	// Draft07 collects shared definitions under "definitions".
+	Draft07 SchemaDraft = "draft-07"
+)
+
+// defsKeyword returns the keyword holding shared definitions in the draft.
+func (d SchemaDraft) defsKeyword() string {
+	if d == Draft07 {
+		return "definitions"
+	}
+	return "$defs"
+}
+
+// schemaURL returns the meta-schema URL identifying the draft in a $schema keyword.
+func (d SchemaDraft) schemaURL() string {
+	if d == Draft07 {
+		return "http://json-schema.org/draft-07/schema#"
+	}
+	return "https://json-schema.org/draft/2020-12/schema"
+}
+
+// SchemaOptions configures schema generation through GenerateSchemaWithOptions.
+// The zero value produces the same output as GenerateSchema.
+type SchemaOptions struct {
+	// Draft selects the JSON Schema vocabulary used for the output. It defaults to Draft202012.
+	Draft SchemaDraft
+
+	// NullablePointers marks pointer fields as nullable, so a *string field is
+	// emitted with "type": ["string", "null"].
+	NullablePointers bool
+
+	// PropertyOrder records the struct declaration order of each object's properties under
+	// the x-order extension, since JSON objects do not preserve key order.
+	PropertyOrder bool
+
+	// TypeMapper overrides the schema type of the Go types it reports true for, such as mapping
+	// int64 to String for consumers that would lose precision. It is consulted before SchemaProvider
+	// and the built-in mappings, and is never called with pointer types, which are unwrapped first.
+	// Field tags and values registered through RegisterEnum and RegisterFormat still apply.
+	// Only scalar types may be returned: generation fails for Array and Object, which need items or
+	// properties the mapper cannot supply, so describe such types with a SchemaProvider.
+	TypeMapper func(t reflect.Type) (DataType, bool)
+
+	// AllowNonStringMapKeys describes maps keyed by integers or by types implementing
+	// encoding.TextMarshaler as objects, since encoding/json writes those keys as strings.
+	// Without it, maps whose keys are not strings are rejected.
+	AllowNonStringMapKeys bool
+
+	// MaxDepth limits how many objects and arrays may be nested inside each other, counting the
+	// root object as the first level, so deeply nested types fail instead of producing huge schemas.
+	// Zero means no limit.
+	MaxDepth int
+
+	// OptionalByDefault makes fields optional unless marked with required:"true", reversing the
+	// default where every field without omitempty is required. A const tag still makes a field required.
+	OptionalByDefault bool
+
+	// Language selects which language-suffixed description tag, such as description_es for "es",
+	// becomes the canonical description of a field. Fields without a tag for the language keep
+	// their description tag. Empty means the description tag is always used.
+	Language string
+
+	// SkipUnsupportedFields omits struct fields whose types cannot be described, such as func and
+	// chan fields, as if they were tagged json:"-", instead of failing the whole schema.
+	SkipUnsupportedFields bool
+
+	// OnSkippedField, if set, is called for every field omitted by SkipUnsupportedFields with a
+	// warning such as "Config.Callback: unsupported type: func".
+	OnSkippedField func(warning string)
+
+	// Descriptions sets the description of properties by their JSON path, such as "address.city",
+	// overriding description tags so copy can live outside the code, e.g. in a localization file.
+	// Array items are addressed with "[]" as in "orders[].id", map values with "*", and the root with "".
+	// Properties inside the $defs of recursive types cannot be addressed. A path that matches no
+	// property is an error, which catches entries left behind by renamed fields.
+	Descriptions map[string]string
+
+	// TagName is the struct tag that names fields and marks them optional, for structs decoded by another
+	// encoder, such as "mapstructure". It is read in the format of the json tag: a name followed by
+	// options such as omitempty, with "-" skipping the field. Embedded structs are nested under their
+	// field name unless the tag has the squash option, as mapstructure does. It defaults to "json".
+	TagName string
 }
 
 // GenerateRawSchema wraps GenerateSchema and returns the JSON marshalled schema.
 // Before marshalling, it validates the generated schema using ValidateDefinition.
 func GenerateRawSchema(v any) (json.RawMessage, error) {
-	def, err := generateSchema(v)
+	def, err := GenerateSchema(v)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +763,132 @@ func GenerateRawSchema(v any) (json.RawMessage, error) {
 	return json.Marshal(def)
 }
 
+// StrictSchema generates a JSON schema Definition compatible with OpenAI strict function calling.
+// Every object lists all of its properties as required and disallows additional properties;
+// properties that would otherwise be optional are made nullable so the model can still omit a value.
+// Map types cannot be expressed in strict mode and return an error.
+func StrictSchema(v any) (*Definition, error) {
+	def, err := GenerateSchema(v)
+	if err != nil {
+		return nil, err
+	}
+	// makeStrict works in place, so detach the schema from definitions shared through a SchemaProvider.
+	def = def.Clone()
+	if err := makeStrict(def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// makeStrict recursively rewrites def in place to satisfy the strict-mode rules described in StrictSchema.
+func makeStrict(def *Definition) error {
+	for _, defs := range []map[string]Definition{def.Defs, def.Definitions} {
+		for name, shared := range defs {
+			if err := makeStrict(&shared); err != nil {
+				return fmt.Errorf("definition '%s': %w", name, err)
+			}
+			defs[name] = shared
+		}
+	}
+	if def.Items != nil {
+		if err := makeStrict(def.Items); err != nil {
+			return err
+		}
+	}
+	for _, subschemas := range [][]Definition{def.OneOf, def.AnyOf, def.AllOf} {
+		for i := range subschemas {
+			if err := makeStrict(&subschemas[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if def.Type != Object {
+		return nil
+	}
+	if def.AdditionalProperties != nil && !def.AdditionalProperties.forbidden() {
+		return errors.New("strict schemas do not support objects with additional properties")
+	}
+	def.AdditionalProperties = AllowAdditionalProperties(false)
+
+	// Keep the declared order of required fields and append the optional ones in name order.
+	names := make([]string, 0, len(def.Properties))
+	for name := range def.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := def.Properties[name]
+		if err := makeStrict(&prop); err != nil {
+			return fmt.Errorf("property '%s': %w", name, err)
+		}
+		if !slices.Contains(def.Required, name) {
+			makeNullable(&prop)
+			def.Required = append(def.Required, name)
+		}
+		def.Properties[name] = prop
+	}
+	return nil
+}
+
+// makeNullable makes def accept null as well. Definitions without a type cannot carry the "null" type
+// union, so a $ref is wrapped as anyOf [{$ref}, {"type": "null"}] and other composition gains a null
+// alternative. An enum gains a null value, since the enum would otherwise still reject null. Slices are
+// reallocated rather than appended to in place, as they may be shared with a SchemaProvider.
+func makeNullable(def *Definition) {
+	nullType := Definition{Type: Null}
+	switch {
+	case def.Type != "":
+		def.Nullable = true
+		if len(def.Enum) > 0 && !slices.Contains(def.Enum, nil) {
+			def.Enum = append(slices.Clip(def.Enum), nil)
+		}
+	case def.Ref != "":
+		def.AnyOf = []Definition{{Ref: def.Ref}, nullType}
+		def.Ref = ""
+	case len(def.AnyOf) > 0:
+		if !slices.ContainsFunc(def.AnyOf, func(sub Definition) bool { return sub.Type == Null }) {
+			def.AnyOf = append(slices.Clip(def.AnyOf), nullType)
+		}
+	case len(def.OneOf) > 0:
+		if !slices.ContainsFunc(def.OneOf, func(sub Definition) bool { return sub.Type == Null }) {
+			def.OneOf = append(slices.Clip(def.OneOf), nullType)
+		}
+	}
+}
+
 // ValidateDefinition recursively validates the generated JSON Schema definition.
 // It ensures that required fields exist, arrays have items defined,
 // that enum values are not empty, and that if AdditionalProperties is set,
-// it conforms to accepted types (bool, Definition, or *Definition).
+// it holds either a boolean or a schema, not both, and that schema is valid.
 func ValidateDefinition(def *Definition) error {
+	// Validate the shared definitions referenced through $ref.
+	for _, defs := range []map[string]Definition{def.Defs, def.Definitions} {
+		for name, shared := range defs {
+			if err := ValidateDefinition(&shared); err != nil {
+				return fmt.Errorf("invalid definition '%s': %w", name, err)
+			}
+		}
+	}
+	// Validate the subschemas of composition keywords.
+	for keyword, subschemas := range map[string][]Definition{"oneOf": def.OneOf, "anyOf": def.AnyOf, "allOf": def.AllOf} {
+		for i := range subschemas {
+			if err := ValidateDefinition(&subschemas[i]); err != nil {
+				return fmt.Errorf("invalid %s subschema at position %d: %w", keyword, i, err)
+			}
+		}
+	}
+	// Validate the subschemas of conditional keywords.
+	for keyword, conditional := range map[string]*Definition{"if": def.If, "then": def.Then, "else": def.Else} {
+		if conditional != nil {
+			if err := ValidateDefinition(conditional); err != nil {
+				return fmt.Errorf("invalid %s subschema: %w", keyword, err)
+			}
+		}
+	}
+	// A reference carries no type of its own; its target is validated through $defs.
+	if def.Ref != "" {
+		return nil
+	}
 	switch def.Type {
 	case Object:
 		// Ensure that each required field exists in the Properties map.
@@ -82,20 +904,14 @@ func ValidateDefinition(def *Definition) error {
 			}
 		}
 		// Validate AdditionalProperties if set.
-		if def.AdditionalProperties != nil {
-			switch v := def.AdditionalProperties.(type) {
-			case bool:
-				// Valid – additional properties are either allowed or not.
-			case Definition:
-				if err := ValidateDefinition(&v); err != nil {
-					return fmt.Errorf("invalid AdditionalProperties definition: %w", err)
-				}
-			case *Definition:
-				if err := ValidateDefinition(v); err != nil {
+		if additional := def.AdditionalProperties; additional != nil {
+			if additional.Bool != nil && additional.Schema != nil {
+				return fmt.Errorf("AdditionalProperties cannot be both a boolean and a schema")
+			}
+			if additional.Schema != nil {
+				if err := ValidateDefinition(additional.Schema); err != nil {
 					return fmt.Errorf("invalid AdditionalProperties definition: %w", err)
 				}
-			default:
-				return fmt.Errorf("unsupported type for AdditionalProperties: %T", v)
 			}
 		}
 	case Array:
@@ -107,164 +923,1172 @@ func ValidateDefinition(def *Definition) error {
 			return fmt.Errorf("invalid array items: %w", err)
 		}
 	case String, Number, Integer, Boolean, Null:
-		// For primitive types, validate that if enum is defined, none of the values are empty.
+		// For primitive types, validate that if enum is defined, none of the values are empty;
+		// null is only allowed in the enum of a nullable definition.
 		if len(def.Enum) > 0 {
 			for i, enumVal := range def.Enum {
-				if strings.TrimSpace(enumVal) == "" {
+				if enumVal == nil && !def.Nullable {
+					return fmt.Errorf("enum defined but value at position %d is empty", i)
+				}
+				if str, ok := enumVal.(string); ok && strings.TrimSpace(str) == "" {
 					return fmt.Errorf("enum defined but value at position %d is empty", i)
 				}
 			}
 		}
+	case "":
+		// An untyped definition accepts any JSON value.
 	default:
 		return fmt.Errorf("unsupported schema type '%s'", def.Type)
 	}
-	return nil
+	return nil
+}
+
+// ErrUnsupportedType is wrapped by the errors returned for Go types that cannot be described by a schema,
+// such as func and chan types or interfaces with methods.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// SchemaError describes a Go type that cannot be described by a schema, locating the offending field.
+type SchemaError struct {
+	Path string       // Dotted path of Go field names from the root type, e.g. "User.Settings.Notifier".
+	Kind reflect.Kind // Kind of the offending type.
+	Err  error        // Underlying cause.
+}
+
+// Error returns a human readable description of the failure, prefixed by the field path when known.
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("error generating schema for field '%s': %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// withSchemaPath prefixes the path of the SchemaError in err with name, or wraps err in a new
+// SchemaError located at name when it carries none.
+func withSchemaPath(err error, name string, kind reflect.Kind) error {
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		return &SchemaError{Path: name, Kind: kind, Err: err}
+	}
+	if schemaErr.Path == "" {
+		schemaErr.Path = name
+	} else {
+		schemaErr.Path = name + "." + schemaErr.Path
+	}
+	return err
+}
+
+// GenerateSchema generates a JSON schema Definition for the given value.
+// It uses reflection to derive the schema based on the type of v.
+// Definitions of recursive struct types are collected under the top-level $defs.
+func GenerateSchema(v any) (*Definition, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	return GenerateSchemaForType(reflect.TypeOf(v))
+}
+
+// GenerateSchemaForType generates a JSON schema Definition for the given type,
+// which avoids constructing a throwaway value when only the reflect.Type is at hand.
+func GenerateSchemaForType(t reflect.Type) (*Definition, error) {
+	return generateSchemaForType(t, SchemaOptions{})
+}
+
+// contextType is the reflect.Type of context.Context, accepted as the leading parameter of SchemaForFunc handlers.
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// SchemaForFunc generates the schema of the arguments of a handler function, so a tool definition can be
+// derived from the function itself. fn must be a func taking a single struct parameter, optionally preceded
+// by a context.Context, such as func(context.Context, WeatherArgs) (string, error); pointers to structs are
+// accepted as well. Return values are not inspected.
+func SchemaForFunc(fn any) (*Definition, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil function")
+	}
+	t := reflect.TypeOf(fn)
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("cannot generate schema for %s: not a function", t.String())
+	}
+	params := make([]reflect.Type, 0, t.NumIn())
+	for i := 0; i < t.NumIn(); i++ {
+		params = append(params, t.In(i))
+	}
+	if len(params) == 2 && params[0] == contextType {
+		params = params[1:]
+	}
+	if len(params) != 1 || t.IsVariadic() {
+		return nil, fmt.Errorf("cannot generate schema for %s: want a single struct parameter, optionally preceded by context.Context", t.String())
+	}
+	param := params[0]
+	for param.Kind() == reflect.Ptr {
+		param = param.Elem()
+	}
+	if param.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot generate schema for %s: parameter of type %s is not a struct", t.String(), params[0].String())
+	}
+	return GenerateSchemaForType(params[0])
+}
+
+// schemaCache memoizes CachedGenerateSchema results keyed by reflect.Type.
+var schemaCache sync.Map
+
+// CachedGenerateSchema behaves like GenerateSchema but memoizes the result per type, so hot paths
+// that derive the same schema repeatedly only pay for reflection once. The returned Definition is
+// shared between callers and must be treated as read-only; marshalling it concurrently is safe.
+func CachedGenerateSchema(v any) (*Definition, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	t := reflect.TypeOf(v)
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*Definition), nil
+	}
+	def, err := GenerateSchemaForType(t)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := schemaCache.LoadOrStore(t, def)
+	return actual.(*Definition), nil
+}
+
+// GenerateSchemaWithOptions generates a JSON schema Definition for the given value
+// using the provided options to adjust the output.
+func GenerateSchemaWithOptions(v any, opts SchemaOptions) (*Definition, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	return generateSchemaForType(reflect.TypeOf(v), opts)
+}
+
+// GenerateSchemaDocument generates a standalone JSON Schema document for the given value, suitable for
+// exporting to files read by other tools. It behaves like GenerateSchemaWithOptions, and also sets $schema
+// to the URL of the selected draft and, unless the type provides one, a title derived from the type name.
+func GenerateSchemaDocument(v any, opts SchemaOptions) (*Definition, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	t := reflect.TypeOf(v)
+	def, err := generateSchemaForType(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	draft := opts.Draft
+	if draft == "" {
+		draft = Draft202012
+	}
+	def.Schema = draft.schemaURL()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if def.Title == "" {
+		def.Title = schemaTypeName(t)
+	}
+	return def, nil
+}
+
+// generateSchemaForType runs a generation pass for t and attaches any collected definitions to the root
+// under the keyword of the selected draft.
+func generateSchemaForType(t reflect.Type, opts SchemaOptions) (*Definition, error) {
+	if opts.Draft == "" {
+		opts.Draft = Draft202012
+	}
+	if opts.Draft != Draft202012 && opts.Draft != Draft07 {
+		return nil, fmt.Errorf("unsupported schema draft '%s'", opts.Draft)
+	}
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	g := newSchemaGenerator(opts)
+	def, err := g.reflectSchema(t)
+	if err != nil {
+		// Root the field path at the name of the generated type.
+		root := t
+		for root.Kind() == reflect.Ptr {
+			root = root.Elem()
+		}
+		var schemaErr *SchemaError
+		if root.Name() != "" && errors.As(err, &schemaErr) && schemaErr.Path != "" {
+			schemaErr.Path = schemaTypeName(root) + "." + schemaErr.Path
+		}
+		return nil, err
+	}
+	if len(opts.Descriptions) > 0 {
+		// Work on a copy so definitions shared through a SchemaProvider are not modified.
+		def = def.Clone()
+		matched := make(map[string]bool, len(opts.Descriptions))
+		applyDescriptions(def, "", opts.Descriptions, matched)
+		for _, path := range slices.Sorted(maps.Keys(opts.Descriptions)) {
+			if !matched[path] {
+				return nil, fmt.Errorf("description for unknown property '%s'", path)
+			}
+		}
+	}
+	if len(g.defs) > 0 {
+		if opts.Draft == Draft07 {
+			def.Definitions = g.defs
+		} else {
+			def.Defs = g.defs
+		}
+	}
+	return def, nil
+}
+
+// schemaGenerator holds the state shared across a single schema generation pass.
+type schemaGenerator struct {
+	opts      SchemaOptions           // Options supplied by the caller.
+	visiting  map[reflect.Type]bool   // Struct types currently being generated.
+	recursive map[reflect.Type]bool   // Struct types that reference themselves.
+	defs      map[string]Definition   // Definitions of recursive types, keyed by type name.
+	defNames  map[reflect.Type]string // $defs keys assigned to recursive types.
+	depth     int                     // Number of objects and arrays enclosing the current type.
+}
+
+// applyDescriptions sets the description of def and its nested properties from descriptions, keyed by
+// their JSON path below path, and records each path it finds in matched.
+func applyDescriptions(def *Definition, path string, descriptions map[string]string, matched map[string]bool) {
+	if description, ok := descriptions[path]; ok {
+		def.Description = description
+		matched[path] = true
+	}
+	for name, prop := range def.Properties {
+		applyDescriptions(&prop, joinPath(path, name), descriptions, matched)
+		def.Properties[name] = prop
+	}
+	if def.Items != nil {
+		applyDescriptions(def.Items, path+"[]", descriptions, matched)
+	}
+	if def.describesMap() {
+		applyDescriptions(def.AdditionalProperties.Schema, joinPath(path, "*"), descriptions, matched)
+	}
+}
+
+// newSchemaGenerator creates a schemaGenerator ready for a new generation pass.
+func newSchemaGenerator(opts SchemaOptions) *schemaGenerator {
+	return &schemaGenerator{
+		opts:      opts,
+		visiting:  make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+		defs:      make(map[string]Definition),
+		defNames:  make(map[reflect.Type]string),
+	}
+}
+
+// defName returns the $defs key of the recursive type t. Types whose schemaTypeName is already taken by
+// another type, such as Page[a.Item] and Page[b.Item], get a numeric suffix so their definitions do not collide.
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+	taken := make(map[string]bool, len(g.defNames))
+	for _, name := range g.defNames {
+		taken[name] = true
+	}
+	base := schemaTypeName(t)
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.defNames[t] = name
+	return name
+}
+
+// tagName returns the struct tag naming fields, as selected with SchemaOptions.TagName.
+func (g *schemaGenerator) tagName() string {
+	if g.opts.TagName == "" {
+		return "json"
+	}
+	return g.opts.TagName
+}
+
+// enter descends into the object or array type t, enforcing the MaxDepth option.
+// Callers decrement g.depth once t has been generated.
+func (g *schemaGenerator) enter(t reflect.Type) error {
+	if g.opts.MaxDepth > 0 && g.depth >= g.opts.MaxDepth {
+		return &SchemaError{Kind: t.Kind(), Err: fmt.Errorf("schema exceeds maximum depth of %d", g.opts.MaxDepth)}
+	}
+	g.depth++
+	return nil
+}
+
+// NoParams returns the parameters schema recommended for tools that take no arguments: an object with
+// an explicit empty properties map and no additional properties, which strict function calling accepts
+// and which models reliably answer with {}. Execute receives the arguments as sent, which may be {} or empty.
+func NoParams() *Definition {
+	return &Definition{
+		Type:                 Object,
+		Properties:           map[string]Definition{},
+		AdditionalProperties: AllowAdditionalProperties(false),
+	}
 }
 
-// GenerateSchema generates a JSON schema Definition for the given value.
-// It uses reflection to derive the schema based on the type of v.
-func generateSchema(v any) (*Definition, error) {
-	return reflectSchema(reflect.TypeOf(v))
+// NewOneOf returns a Definition matching exactly one of the given subschemas.
+// It is useful for SchemaProvider implementations describing union types.
+func NewOneOf(subschemas ...Definition) *Definition {
+	return &Definition{OneOf: subschemas}
+}
+
+// NewAnyOf returns a Definition matching at least one of the given subschemas.
+func NewAnyOf(subschemas ...Definition) *Definition {
+	return &Definition{AnyOf: subschemas}
+}
+
+// NewAllOf returns a Definition matching all of the given subschemas.
+func NewAllOf(subschemas ...Definition) *Definition {
+	return &Definition{AllOf: subschemas}
+}
+
+// SchemaProvider is implemented by types that describe their own JSON schema,
+// such as types with a custom json.Marshaler whose encoding differs from their Go structure.
+// The returned Definition is used verbatim instead of reflecting on the type.
+type SchemaProvider interface {
+	JSONSchema() *Definition
+}
+
+// schemaProviderType is the reflect.Type of the SchemaProvider interface.
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+// timeType is the reflect.Type of time.Time, which encoding/json marshals as an RFC 3339 string.
+var timeType = reflect.TypeOf(time.Time{})
+
+// rawMessageType is the reflect.Type of json.RawMessage, which may hold any JSON value.
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// jsonNumberType is the reflect.Type of json.Number, which is a string type encoded as a JSON number.
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// typeArgumentQualifier matches the package path qualifying a type argument, such as "github.com/acme/api."
+// in "Response[github.com/acme/api.User]".
+var typeArgumentQualifier = regexp.MustCompile(`[\w./-]*\.`)
+
+// schemaTypeName returns the name of t as used for $defs keys and titles. Instantiations of generic types
+// keep their type arguments without package paths, so Response[github.com/acme/api.User] becomes
+// Response[User], which needs no escaping inside a $ref.
+func schemaTypeName(t reflect.Type) string {
+	name := t.Name()
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+	return base + "[" + typeArgumentQualifier.ReplaceAllString(args, "")
+}
+
+// reflectSchema generates a JSON schema Definition by reflecting on the provided type,
+// then applies any values registered for the type through RegisterEnum and RegisterFormat.
+func (g *schemaGenerator) reflectSchema(t reflect.Type) (*Definition, error) {
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil value")
+	}
+	d, err := g.reflectType(t)
+	if err != nil {
+		return nil, err
+	}
+	if enum, ok := lookupEnum(t); ok {
+		d.Enum = append([]any(nil), enum.values...)
+		d.EnumVarNames = append([]string(nil), enum.names...)
+	}
+	if format, ok := lookupFormat(t); ok {
+		d.Format = format
+	}
+	return d, nil
 }
 
-// reflectSchema generates a JSON schema Definition by reflecting on the provided type.
-func reflectSchema(t reflect.Type) (*Definition, error) {
+// reflectType derives the JSON schema Definition for a type from its kind.
+func (g *schemaGenerator) reflectType(t reflect.Type) (*Definition, error) {
 	var d Definition
+	// A caller-supplied mapping takes precedence over everything else.
+	if g.opts.TypeMapper != nil && t.Kind() != reflect.Ptr {
+		if mapped, ok := g.opts.TypeMapper(t); ok {
+			switch mapped {
+			case Number, Integer, String, Boolean, Null:
+			case Array, Object:
+				return nil, &SchemaError{Kind: t.Kind(), Err: fmt.Errorf("TypeMapper maps %s to '%s', which needs items or properties; implement SchemaProvider instead", t, mapped)}
+			default:
+				return nil, &SchemaError{Kind: t.Kind(), Err: fmt.Errorf("TypeMapper maps %s to unknown type '%s'", t, mapped)}
+			}
+			d.Type = mapped
+			return &d, nil
+		}
+	}
+	// Types that describe themselves take precedence over structural reflection.
+	// Pointers are unwrapped first so the provider is always called on a non-nil value; interface types
+	// have no value to call it on, so they are described like any other interface.
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && (t.Implements(schemaProviderType) || reflect.PointerTo(t).Implements(schemaProviderType)) {
+		provided := reflect.New(t).Interface().(SchemaProvider).JSONSchema()
+		if provided == nil {
+			return nil, fmt.Errorf("schema provider %s returned a nil definition", t.String())
+		}
+		// Copy the definition so field tags applied later do not modify the provider's value.
+		d = *provided
+		return &d, nil
+	}
+	// time.Time is a struct, but it is encoded as a date-time string.
+	if t == timeType {
+		d.Type = String
+		d.Format = "date-time"
+		return &d, nil
+	}
+	// json.Number is a string, but it is encoded as a number.
+	if t == jsonNumberType {
+		d.Type = Number
+		return &d, nil
+	}
 	switch t.Kind() {
 	case reflect.String:
 		d.Type = String
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.Type = Integer
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Unsigned integers can never be negative.
 		d.Type = Integer
+		zero := 0.0
+		d.Minimum = &zero
 	case reflect.Float32, reflect.Float64:
 		d.Type = Number
 	case reflect.Bool:
 		d.Type = Boolean
 	case reflect.Slice, reflect.Array:
+		// json.RawMessage holds arbitrary JSON, so it is left unconstrained.
+		if t == rawMessageType {
+			return &d, nil
+		}
+		// encoding/json encodes byte slices as base64 strings.
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			d.Type = String
+			d.ContentEncoding = "base64"
+			return &d, nil
+		}
 		d.Type = Array
-		// Recursively generate the schema for the element type.
-		items, err := reflectSchema(t.Elem())
+		if err := g.enter(t); err != nil {
+			return nil, err
+		}
+		// Recursively generate the schema for the element type; pointer elements such as
+		// []*User are dereferenced like any other pointer, so the items are the object schema.
+		items, err := g.reflectSchema(t.Elem())
+		g.depth--
 		if err != nil {
 			return nil, err
 		}
 		d.Items = items
 	case reflect.Struct:
-		d.Type = Object
-		// Disallow additional properties by default.
-		d.AdditionalProperties = false
-		objDef, err := reflectSchemaObject(t)
+		// A type that is already being generated is recursive: reference it instead of recursing forever.
+		if g.visiting[t] {
+			g.recursive[t] = true
+			return &Definition{Ref: "#/" + g.opts.Draft.defsKeyword() + "/" + g.defName(t)}, nil
+		}
+		if err := g.enter(t); err != nil {
+			return nil, err
+		}
+		g.visiting[t] = true
+		objDef, err := g.reflectSchemaObject(t, nil)
+		delete(g.visiting, t)
+		g.depth--
 		if err != nil {
 			return nil, err
 		}
+		if g.recursive[t] {
+			g.defs[g.defName(t)] = *objDef
+		}
 		d = *objDef
 	case reflect.Ptr:
-		// Dereference pointer and generate schema for the underlying type.
-		definition, err := reflectSchema(t.Elem())
+		// Dereference pointer and generate schema for the underlying type, so *[]User is an array.
+		definition, err := g.reflectSchema(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		d = *definition
+	case reflect.Map:
+		// JSON objects only support string keys, so reject any other key kind unless
+		// encoding/json would convert it to a string and the caller opted in.
+		if t.Key().Kind() != reflect.String && !(g.opts.AllowNonStringMapKeys && textualMapKey(t.Key())) {
+			return nil, &SchemaError{Kind: t.Key().Kind(), Err: fmt.Errorf("unsupported map key type: %s", t.Key().Kind().String())}
+		}
+		d.Type = Object
+		if err := g.enter(t); err != nil {
+			return nil, err
+		}
+		// Describe the map values through additionalProperties.
+		values, err := g.reflectSchema(t.Elem())
+		g.depth--
+		if err != nil {
+			return nil, err
+		}
+		d.AdditionalProperties = AdditionalPropertiesSchema(values)
+	case reflect.Interface:
+		// An empty interface (any) accepts any JSON value; interfaces with methods cannot be described.
+		if t.NumMethod() > 0 {
+			return nil, &SchemaError{Kind: reflect.Interface, Err: fmt.Errorf("%w: non-empty interface %s", ErrUnsupportedType, t.String())}
+		}
 	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128,
-		reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Chan, reflect.Func,
 		reflect.UnsafePointer:
-		return nil, fmt.Errorf("unsupported type: %s", t.Kind().String())
+		return nil, &SchemaError{Kind: t.Kind(), Err: fmt.Errorf("%w: %s", ErrUnsupportedType, t.Kind().String())}
 	default:
 		// Handle other unexpected types if necessary.
 	}
 	return &d, nil
 }
 
+// textualMapKey reports whether encoding/json encodes map keys of type t as strings: integer keys are
+// formatted in decimal and keys implementing encoding.TextMarshaler use their text form.
+func textualMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return t.Implements(textMarshalerType)
+}
+
+// textMarshalerType is the reflect.Type of the encoding.TextMarshaler interface.
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
 // processField is a helper function that processes a struct field and generates its associated JSON schema component.
 // It returns the JSON tag name, the generated schema, a flag indicating whether the field is required, and an error if any.
-func processField(field reflect.StructField) (jsonTag string, schema *Definition, required bool, err error) {
-	// Retrieve the JSON tag from the field.
-	jsonTag = field.Tag.Get("json")
+// Fields are required unless 'omitempty' is set (or OptionalByDefault is enabled), and an explicit
+// "required" tag overrides both.
+func (g *schemaGenerator) processField(field reflect.StructField) (jsonTag string, schema *Definition, required bool, err error) {
+	// Retrieve the JSON tag, or the tag selected with TagName, from the field.
+	jsonTag = field.Tag.Get(g.tagName())
 	if jsonTag == "-" {
 		return "", nil, false, nil // Field is ignored.
 	}
-	required = true // By default, the field is required.
+	if field.Tag.Get("schema") == "-" {
+		return "", nil, false, nil // Field is serialized but hidden from the schema.
+	}
+	required = !g.opts.OptionalByDefault // Fields are required unless OptionalByDefault is set.
+	omitEmpty := false                   // Whether the 'omitempty' option is present.
+	asString := false                    // Whether the 'string' option encodes the value as a JSON string.
 
 	if jsonTag == "" {
 		jsonTag = field.Name
 	} else {
 		parts := strings.Split(jsonTag, ",")
 		jsonTag = parts[0]
-		// If 'omitempty' is specified, the field is not required.
 		for _, opt := range parts[1:] {
-			if strings.TrimSpace(opt) == "omitempty" {
+			switch strings.TrimSpace(opt) {
+			case "omitempty":
+				// If 'omitempty' is specified, the field is not required.
+				omitEmpty = true
 				required = false
-				break
+			case "string":
+				asString = true
 			}
 		}
 	}
 
 	// Recursively generate the schema for the field's type.
-	schema, err = reflectSchema(field.Type)
+	schema, err = g.reflectSchema(field.Type)
 	if err != nil {
 		return "", nil, false, err
 	}
 
+	// The 'string' option makes encoding/json quote numbers and booleans, so describe them as strings.
+	if asString {
+		switch schema.Type {
+		case Integer, Number, Boolean:
+			schema.Type = String
+			schema.Minimum = nil
+		}
+	}
+
+	// The "type" tag overrides the reflected type, such as describing an int64 timestamp as a string.
+	// Keywords derived from the reflected type are dropped, since they rarely fit the new one; restating
+	// the reflected type keeps them. Arrays and objects need the reflected items or properties, so they
+	// cannot replace another type.
+	if typeTag := strings.TrimSpace(field.Tag.Get("type")); typeTag != "" {
+		override := DataType(typeTag)
+		switch override {
+		case Object, Number, Integer, String, Array, Null, Boolean:
+		default:
+			return "", nil, false, fmt.Errorf("invalid 'type' tag on field '%s': unknown type '%s'", field.Name, typeTag)
+		}
+		if schema.Type != override {
+			if override == Array || override == Object {
+				return "", nil, false, fmt.Errorf("invalid 'type' tag on field '%s': type '%s' requires a field of that kind", field.Name, typeTag)
+			}
+			schema = &Definition{Type: override}
+		}
+	}
+
+	// Set the title if provided via the tag.
+	if title := strings.TrimSpace(field.Tag.Get("title")); title != "" {
+		schema.Title = title
+	}
+
 	// Set the description if provided via the tag.
 	if description := strings.TrimSpace(field.Tag.Get("description")); description != "" {
 		schema.Description = description
 	}
 
+	// Keep developer notes from the "comment" tag under $comment.
+	if comment := strings.TrimSpace(field.Tag.Get("comment")); comment != "" {
+		schema.Comment = comment
+	}
+
+	// Collect translations from language-suffixed tags such as description_es under x-descriptions.
+	if descriptions := localizedDescriptions(field.Tag); len(descriptions) > 0 {
+		schema.Descriptions = descriptions
+		if description, ok := descriptions[g.opts.Language]; ok {
+			schema.Description = description
+		}
+	}
+
+	// Describe the items of a slice field with the "descriptionItems" tag.
+	if itemsDescription := strings.TrimSpace(field.Tag.Get("descriptionItems")); itemsDescription != "" {
+		if schema.Type != Array || schema.Items == nil {
+			return "", nil, false, fmt.Errorf("tag 'descriptionItems' on field '%s' requires type '%s', got '%s'", field.Name, Array, schema.Type)
+		}
+		// Copy the items so a definition shared through a SchemaProvider is not modified.
+		items := *schema.Items
+		items.Description = itemsDescription
+		schema.Items = &items
+	}
+
+	// Constrain the items of a slice field with the "items*" tags, such as "itemsMinLength".
+	if err = applyItemsTags(field, schema); err != nil {
+		return "", nil, false, err
+	}
+
+	// Set the format hint if provided via the tag; the value is passed through verbatim.
+	if format := strings.TrimSpace(field.Tag.Get("format")); format != "" {
+		schema.Format = format
+	}
+
+	// Describe string fields carrying encoded data with the "contentEncoding" and "contentMediaType" tags.
+	for _, tag := range []string{"contentEncoding", "contentMediaType"} {
+		value := strings.TrimSpace(field.Tag.Get(tag))
+		if value == "" {
+			continue
+		}
+		if schema.Type != String {
+			return "", nil, false, fmt.Errorf("tag '%s' on field '%s' requires type '%s', got '%s'", tag, field.Name, String, schema.Type)
+		}
+		if tag == "contentEncoding" {
+			schema.ContentEncoding = value
+		} else {
+			schema.ContentMediaType = value
+		}
+	}
+
 	// Handle the "enum" tag to specify enumeration values.
-	if enumTag := field.Tag.Get("enum"); enumTag != "" {
-		var enumValues []string
-		for _, v := range strings.Split(enumTag, ",") {
-			if trimmed := strings.TrimSpace(v); trimmed != "" {
-				enumValues = append(enumValues, trimmed)
-			}
+	// Values for integer, number and boolean fields are parsed so they serialize unquoted.
+	if enumTag, ok := field.Tag.Lookup("enum"); ok {
+		enumValues, pErr := parseEnumValues(schema.Type, enumTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'enum' tag on field '%s': %w", field.Name, pErr)
+		}
+		schema.Enum = enumValues
+		schema.EnumVarNames = nil
+		schema.EnumDescriptions = nil
+	}
+
+	// Explain each enum value with the "enumDescriptions" tag, aligned by position with the values.
+	if descriptionsTag := field.Tag.Get("enumDescriptions"); descriptionsTag != "" {
+		descriptions := strings.Split(descriptionsTag, ",")
+		for i := range descriptions {
+			descriptions[i] = strings.TrimSpace(descriptions[i])
+		}
+		if len(descriptions) != len(schema.Enum) {
+			return "", nil, false, fmt.Errorf("invalid 'enumDescriptions' tag on field '%s': %d descriptions for %d enum values", field.Name, len(descriptions), len(schema.Enum))
+		}
+		schema.EnumDescriptions = descriptions
+	}
+
+	// Handle the "minimum" and "maximum" tags for numeric fields.
+	if err = parseNumericTag(field, schema, "minimum", &schema.Minimum); err != nil {
+		return "", nil, false, err
+	}
+	if err = parseNumericTag(field, schema, "maximum", &schema.Maximum); err != nil {
+		return "", nil, false, err
+	}
+
+	// Handle the "exclusiveMinimum" and "exclusiveMaximum" tags; each side takes one kind of bound.
+	if err = parseNumericTag(field, schema, "exclusiveMinimum", &schema.ExclusiveMinimum); err != nil {
+		return "", nil, false, err
+	}
+	if err = parseNumericTag(field, schema, "exclusiveMaximum", &schema.ExclusiveMaximum); err != nil {
+		return "", nil, false, err
+	}
+	if schema.Minimum != nil && schema.ExclusiveMinimum != nil {
+		if field.Tag.Get("minimum") != "" {
+			return "", nil, false, fmt.Errorf("field '%s' cannot define both 'minimum' and 'exclusiveMinimum'", field.Name)
+		}
+		// The implied minimum of an unsigned integer gives way to an explicit exclusive bound.
+		schema.Minimum = nil
+	}
+	if schema.Maximum != nil && schema.ExclusiveMaximum != nil {
+		return "", nil, false, fmt.Errorf("field '%s' cannot define both 'maximum' and 'exclusiveMaximum'", field.Name)
+	}
+
+	// Handle the "multipleOf" tag, which must be strictly positive.
+	if err = parseNumericTag(field, schema, "multipleOf", &schema.MultipleOf); err != nil {
+		return "", nil, false, err
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf <= 0 {
+		return "", nil, false, fmt.Errorf("invalid 'multipleOf' tag on field '%s': must be greater than 0", field.Name)
+	}
+
+	// Handle the "minLength" and "maxLength" tags for string fields.
+	if err = parseCountTag(field, schema, "minLength", String, &schema.MinLength); err != nil {
+		return "", nil, false, err
+	}
+	if err = parseCountTag(field, schema, "maxLength", String, &schema.MaxLength); err != nil {
+		return "", nil, false, err
+	}
+
+	// Handle the "pattern" tag, making sure the regular expression compiles.
+	if pattern := field.Tag.Get("pattern"); pattern != "" {
+		if _, rErr := regexp.Compile(pattern); rErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'pattern' tag on field '%s': %w", field.Name, rErr)
+		}
+		schema.Pattern = pattern
+	}
+
+	// Handle the "minItems", "maxItems" and "uniqueItems" tags for array fields.
+	if err = parseCountTag(field, schema, "minItems", Array, &schema.MinItems); err != nil {
+		return "", nil, false, err
+	}
+	if err = parseCountTag(field, schema, "maxItems", Array, &schema.MaxItems); err != nil {
+		return "", nil, false, err
+	}
+	if uniqueTag := strings.TrimSpace(field.Tag.Get("uniqueItems")); uniqueTag != "" {
+		if schema.Type != Array {
+			return "", nil, false, fmt.Errorf("tag 'uniqueItems' on field '%s' requires type '%s', got '%s'", field.Name, Array, schema.Type)
 		}
-		if len(enumValues) > 0 {
-			schema.Enum = enumValues
+		unique, pErr := strconv.ParseBool(uniqueTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'uniqueItems' tag on field '%s': %w", field.Name, pErr)
+		}
+		schema.UniqueItems = unique
+	}
+
+	// Handle the "default" tag, parsing the literal according to the field's schema type.
+	if defaultTag := field.Tag.Get("default"); defaultTag != "" {
+		value, pErr := parseSchemaValue(schema.Type, defaultTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'default' tag on field '%s': %w", field.Name, pErr)
+		}
+		schema.Default = value
+	}
+
+	// Handle the "examples" tag: a JSON array, or a comma-separated list parsed by the field's schema type.
+	if examplesTag := strings.TrimSpace(field.Tag.Get("examples")); examplesTag != "" {
+		examples, pErr := parseExamples(schema.Type, examplesTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'examples' tag on field '%s': %w", field.Name, pErr)
+		}
+		schema.Examples = examples
+	}
+
+	// Mark the field as deprecated with the "deprecated" tag.
+	if err = parseBoolTag(field, "deprecated", &schema.Deprecated); err != nil {
+		return "", nil, false, err
+	}
+
+	// Mark fields only returned by or only sent to the tool with the "readOnly" and "writeOnly" tags.
+	if err = parseBoolTag(field, "readOnly", &schema.ReadOnly); err != nil {
+		return "", nil, false, err
+	}
+	if err = parseBoolTag(field, "writeOnly", &schema.WriteOnly); err != nil {
+		return "", nil, false, err
+	}
+	if schema.ReadOnly && schema.WriteOnly {
+		return "", nil, false, fmt.Errorf("field '%s' cannot be both 'readOnly' and 'writeOnly'", field.Name)
+	}
+
+	// Handle the "additionalProperties" tag, which opens a struct object to undeclared properties.
+	if openTag := strings.TrimSpace(field.Tag.Get("additionalProperties")); openTag != "" {
+		if schema.Type != Object || schema.describesMap() {
+			return "", nil, false, fmt.Errorf("tag 'additionalProperties' on field '%s' requires a struct type, got '%s'", field.Name, field.Type)
+		}
+		open, pErr := strconv.ParseBool(openTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'additionalProperties' tag on field '%s': %w", field.Name, pErr)
+		}
+		if open {
+			schema.AdditionalProperties = nil
+		} else {
+			schema.AdditionalProperties = AllowAdditionalProperties(false)
+		}
+	}
+
+	// Handle the "const" tag for fixed values such as union discriminators.
+	// A const field is always required unless 'omitempty' makes it optional.
+	if constTag := field.Tag.Get("const"); constTag != "" {
+		value, pErr := parseSchemaValue(schema.Type, constTag)
+		if pErr != nil {
+			return "", nil, false, fmt.Errorf("invalid 'const' tag on field '%s': %w", field.Name, pErr)
+		}
+		schema.Const = value
+		if !omitEmpty {
+			required = true
 		}
 	}
 
 	// Override the default required value using the "required" tag if provided.
+	// The tag has the last word: required:"false" makes a field without 'omitempty' optional,
+	// and required:"true" keeps a field with 'omitempty' (or a 'const' tag) required.
 	if reqTag := field.Tag.Get("required"); reqTag != "" {
 		if parsed, pErr := strconv.ParseBool(reqTag); pErr == nil {
 			required = parsed
 		}
 	}
 
+	// Pointer fields may hold null when nullable pointers are enabled. This comes last so enum tags
+	// gain their null value and references to recursive types are wrapped as anyOf with null.
+	if g.opts.NullablePointers && field.Type.Kind() == reflect.Ptr {
+		makeNullable(schema)
+	}
+
 	return jsonTag, schema, required, nil
 }
 
+// itemsTags lists the tags that constrain the items of a slice field rather than the field itself.
+var itemsTags = []string{
+	"itemsFormat", "itemsEnum", "itemsMinimum", "itemsMaximum",
+	"itemsMinLength", "itemsMaxLength", "itemsPattern",
+}
+
+// applyItemsTags applies the "items*" tags of a slice field to the schema of its items, so element
+// constraints can be expressed even when the field's type is a named slice such as type Tags []string.
+func applyItemsTags(field reflect.StructField, schema *Definition) error {
+	present := slices.ContainsFunc(itemsTags, func(tag string) bool { return field.Tag.Get(tag) != "" })
+	if !present {
+		return nil
+	}
+	if schema.Type != Array || schema.Items == nil {
+		return fmt.Errorf("tags 'items*' on field '%s' require type '%s', got '%s'", field.Name, Array, schema.Type)
+	}
+	// Copy the items so a definition shared through a SchemaProvider is not modified.
+	items := *schema.Items
+	schema.Items = &items
+
+	if format := strings.TrimSpace(field.Tag.Get("itemsFormat")); format != "" {
+		items.Format = format
+	}
+	if enumTag := field.Tag.Get("itemsEnum"); enumTag != "" {
+		values, err := parseEnumValues(items.Type, enumTag)
+		if err != nil {
+			return fmt.Errorf("invalid 'itemsEnum' tag on field '%s': %w", field.Name, err)
+		}
+		items.Enum = values
+		items.EnumVarNames = nil
+	}
+	if err := parseNumericTag(field, &items, "itemsMinimum", &items.Minimum); err != nil {
+		return err
+	}
+	if err := parseNumericTag(field, &items, "itemsMaximum", &items.Maximum); err != nil {
+		return err
+	}
+	if err := parseCountTag(field, &items, "itemsMinLength", String, &items.MinLength); err != nil {
+		return err
+	}
+	if err := parseCountTag(field, &items, "itemsMaxLength", String, &items.MaxLength); err != nil {
+		return err
+	}
+	if pattern := field.Tag.Get("itemsPattern"); pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid 'itemsPattern' tag on field '%s': %w", field.Name, err)
+		}
+		items.Pattern = pattern
+	}
+	return nil
+}
+
+// localizedDescriptions returns the non-empty description_<lang> tags of tag keyed by language.
+// reflect.StructTag only supports lookups by key, so the tag is scanned using the same conventions.
+func localizedDescriptions(tag reflect.StructTag) map[string]string {
+	var descriptions map[string]string
+	rest := string(tag)
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		key, value, ok := strings.Cut(rest, ":")
+		if !ok || key == "" || strings.ContainsAny(key, " \"\x7f") || !strings.HasPrefix(value, `"`) {
+			return descriptions
+		}
+		end := 1
+		for end < len(value) && value[end] != '"' {
+			if value[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(value) {
+			return descriptions
+		}
+		quoted := value[:end+1]
+		rest = value[end+1:]
+
+		lang, found := strings.CutPrefix(key, "description_")
+		if !found || lang == "" {
+			continue
+		}
+		text, err := strconv.Unquote(quoted)
+		if text = strings.TrimSpace(text); err != nil || text == "" {
+			continue
+		}
+		if descriptions == nil {
+			descriptions = make(map[string]string)
+		}
+		descriptions[lang] = text
+	}
+}
+
+// parseEnumValues parses the comma-separated values of an enum tag. Values for integer, number and
+// boolean types are parsed so they serialize unquoted; empty entries are skipped,
+// and a tag without any value is an error so a typo such as enum:"," does not silently drop the enum.
+func parseEnumValues(t DataType, value string) ([]any, error) {
+	var values []any
+	for _, v := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(v)
+		if trimmed == "" {
+			continue
+		}
+		switch t {
+		case Integer, Number, Boolean:
+			parsed, err := parseSchemaValue(t, trimmed)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, parsed)
+		default:
+			values = append(values, trimmed)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values in '%s'", value)
+	}
+	return values, nil
+}
+
+// parseNumericTag reads a numeric constraint tag (such as "minimum") from a struct field
+// into dest. dest is left untouched if the tag is absent, so defaults derived from the
+// field type survive. It returns an error if the value does not parse as a number or the
+// tag is used on a field whose schema type is neither Integer nor Number.
+func parseNumericTag(field reflect.StructField, schema *Definition, tag string, dest **float64) error {
+	value := strings.TrimSpace(field.Tag.Get(tag))
+	if value == "" {
+		return nil
+	}
+	if schema.Type != Integer && schema.Type != Number {
+		return fmt.Errorf("tag '%s' on field '%s' requires a numeric type, got '%s'", tag, field.Name, schema.Type)
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid '%s' tag on field '%s': %w", tag, field.Name, err)
+	}
+	*dest = &parsed
+	return nil
+}
+
+// parseBoolTag reads a boolean flag tag (such as "deprecated") from a struct field into dest.
+// dest is left untouched if the tag is absent, and an error is returned if the value does
+// not parse as a bool.
+func parseBoolTag(field reflect.StructField, tag string, dest *bool) error {
+	value := strings.TrimSpace(field.Tag.Get(tag))
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid '%s' tag on field '%s': %w", tag, field.Name, err)
+	}
+	*dest = parsed
+	return nil
+}
+
+// parseCountTag reads a non-negative integer constraint tag (such as "minLength") from a struct
+// field into dest. dest is left untouched if the tag is absent. It returns an error if the value
+// is not a non-negative integer or the field's schema type does not match the type the tag applies to.
+func parseCountTag(field reflect.StructField, schema *Definition, tag string, want DataType, dest **int) error {
+	value := strings.TrimSpace(field.Tag.Get(tag))
+	if value == "" {
+		return nil
+	}
+	if schema.Type != want {
+		return fmt.Errorf("tag '%s' on field '%s' requires type '%s', got '%s'", tag, field.Name, want, schema.Type)
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("invalid '%s' tag on field '%s': must be a non-negative integer", tag, field.Name)
+	}
+	*dest = &parsed
+	return nil
+}
+
+// parseExamples parses the value of an "examples" tag. A JSON array is decoded as is;
+// otherwise the value is split on commas and each item is parsed with parseSchemaValue.
+func parseExamples(t DataType, value string) ([]any, error) {
+	if strings.HasPrefix(value, "[") {
+		var examples []any
+		if err := json.Unmarshal([]byte(value), &examples); err == nil {
+			return examples, nil
+		}
+	}
+	var examples []any
+	for _, item := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" {
+			continue
+		}
+		example, err := parseSchemaValue(t, trimmed)
+		if err != nil {
+			return nil, err
+		}
+		examples = append(examples, example)
+	}
+	return examples, nil
+}
+
+// parseSchemaValue converts a literal taken from a struct tag into a Go value matching the given schema type.
+// Integers, numbers and booleans are parsed from their textual form, strings are used verbatim,
+// and arrays or objects are decoded as JSON literals.
+func parseSchemaValue(t DataType, value string) (any, error) {
+	switch t {
+	case Integer:
+		return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case Number:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case Boolean:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case String:
+		return value, nil
+	case Array, Object:
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("values are not supported for schema type '%s'", t)
+	}
+}
+
 // reflectSchemaObject generates a JSON schema Definition for a struct type.
 // It iterates over the exported fields, processes each field, and constructs the schema properties.
-func reflectSchemaObject(t reflect.Type) (*Definition, error) {
+// Fields of embedded structs without a json name are promoted into the parent, mirroring encoding/json.
+// Required fields are listed in struct declaration order, with promoted fields at the position of
+// their embedded struct; the same order is recorded under x-order when PropertyOrder is set.
+// embeddedIn lists the structs that embed t, outermost first, when t is being promoted into them.
+func (g *schemaGenerator) reflectSchemaObject(t reflect.Type, embeddedIn []reflect.Type) (*Definition, error) {
 	def := Definition{
 		Type:                 Object,
-		AdditionalProperties: false,
+		AdditionalProperties: AllowAdditionalProperties(false),
 	}
 	properties := make(map[string]Definition)
-	var requiredFields []string
+	promoted := make(map[string]bool)   // Properties that were promoted from embedded structs.
+	declared := make(map[string]string) // Go field names of the properties declared directly on t.
+	var requiredFields, order []string
 
 	// Iterate over each field in the struct.
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
+
+		// Promote the fields of embedded structs; fields declared directly on t take precedence.
+		if embedded, ok := embeddedStructType(field, g.tagName()); ok {
+			// Only a struct that embeds itself, directly or through other embedded structs, cannot be
+			// promoted; recursion through regular fields is broken by $ref instead.
+			chain := append(slices.Clip(embeddedIn), t)
+			if slices.Contains(chain, embedded) {
+				return nil, fmt.Errorf("recursive embedded struct: %s", schemaTypeName(embedded))
+			}
+			embeddedDef, err := g.reflectSchemaObject(embedded, chain)
+			if err != nil {
+				return nil, withSchemaPath(err, field.Name, embedded.Kind())
+			}
+			for name, prop := range embeddedDef.Properties {
+				if _, exists := properties[name]; exists {
+					continue
+				}
+				properties[name] = prop
+				promoted[name] = true
+			}
+			for _, name := range embeddedDef.Required {
+				if promoted[name] && !slices.Contains(requiredFields, name) {
+					requiredFields = append(requiredFields, name)
+				}
+			}
+			for _, name := range embeddedDef.PropertyOrder {
+				if promoted[name] && !slices.Contains(order, name) {
+					order = append(order, name)
+				}
+			}
+			continue
+		}
+
 		// Skip unexported fields.
 		if !field.IsExported() {
 			continue
 		}
 
-		tag, schema, req, err := processField(field)
+		tag, schema, req, err := g.processField(field)
+		if err != nil && g.opts.SkipUnsupportedFields && errors.Is(err, ErrUnsupportedType) {
+			if g.opts.OnSkippedField != nil {
+				g.opts.OnSkippedField(fmt.Sprintf("%s.%s: %v", schemaTypeName(t), field.Name, err))
+			}
+			continue
+		}
 		if err != nil {
-			return nil, err
+			return nil, withSchemaPath(err, field.Name, field.Type.Kind())
 		}
 		// Skip fields with an empty JSON tag.
 		if tag == "" {
 			continue
 		}
 
+		// Two direct fields resolving to the same property would silently overwrite each other.
+		if other, exists := declared[tag]; exists {
+			return nil, fmt.Errorf("fields '%s' and '%s' both map to property '%s'", other, field.Name, tag)
+		}
+		declared[tag] = field.Name
+
+		// A direct field shadows a property promoted from an embedded struct.
+		if promoted[tag] {
+			delete(promoted, tag)
+			requiredFields = slices.DeleteFunc(requiredFields, func(name string) bool { return name == tag })
+			order = slices.DeleteFunc(order, func(name string) bool { return name == tag })
+		}
+
 		properties[tag] = *schema
 		if req {
 			requiredFields = append(requiredFields, tag)
 		}
+		order = append(order, tag)
 	}
 	def.Properties = properties
 	def.Required = requiredFields
+	if g.opts.PropertyOrder {
+		def.PropertyOrder = order
+	}
 	return &def, nil
 }
+
+// embeddedStructType reports whether the field is an embedded struct (or pointer to struct)
+// whose fields should be promoted, returning the struct type if so.
+// Embedded fields with an explicit name in tagName are treated as regular nested properties.
+// Encoders other than encoding/json, such as mapstructure, nest embedded structs under the field
+// name unless the tag has the squash option, so with any tagName but json only squashed fields are promoted.
+func embeddedStructType(field reflect.StructField, tagName string) (reflect.Type, bool) {
+	if !field.Anonymous || field.Tag.Get("schema") == "-" {
+		return nil, false
+	}
+	parts := strings.Split(field.Tag.Get(tagName), ",")
+	if parts[0] != "" {
+		return nil, false
+	}
+	if tagName != "json" && !slices.ContainsFunc(parts[1:], func(opt string) bool { return strings.TrimSpace(opt) == "squash" }) {
+		return nil, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil, false
+	}
+	return t, true
+}
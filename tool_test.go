@@ -0,0 +1,1700 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// assertJSON fails the test unless got marshals to the same JSON value as want.
+func assertJSON(t *testing.T, got any, want string) {
+	t.Helper()
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var gotValue, wantValue any
+	if err := json.Unmarshal(data, &gotValue); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("unmarshal expectation: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("schema mismatch\n got: %s\nwant: %s", data, want)
+	}
+}
+
+// assertSchema fails the test unless GenerateSchema describes v with the JSON schema want.
+func assertSchema(t *testing.T, v any, want string) {
+	t.Helper()
+	def, err := GenerateSchema(v)
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	assertJSON(t, def, want)
+}
+
+// assertSchemaError fails the test unless GenerateSchema rejects v with an error containing want.
+func assertSchemaError(t *testing.T, v any, want string) {
+	t.Helper()
+	_, err := GenerateSchema(v)
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("GenerateSchema error = %v, want one mentioning %q", err, want)
+	}
+}
+
+func TestGenerateSchemaMaps(t *testing.T) {
+	assertSchema(t, struct {
+		Metrics map[string]float64 `json:"metrics"`
+	}{}, `{"type":"object","properties":{"metrics":{"type":"object","additionalProperties":{"type":"number"}}},"required":["metrics"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Counts map[int]string `json:"counts"`
+	}{}, "unsupported map key type: int")
+}
+
+type schemaTimestamps struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func TestGenerateSchemaTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "field", value: schemaTimestamps{}},
+		{name: "embedded", value: struct {
+			schemaTimestamps
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSchema(t, tt.value, `{"type":"object","properties":{"createdAt":{"type":"string","format":"date-time"}},"required":["createdAt"],"additionalProperties":false}`)
+		})
+	}
+}
+
+func TestGenerateSchemaFormatTag(t *testing.T) {
+	assertSchema(t, struct {
+		ID string `json:"id" format:"uuid"`
+	}{}, `{"type":"object","properties":{"id":{"type":"string","format":"uuid"}},"required":["id"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaNumericRange(t *testing.T) {
+	assertSchema(t, struct {
+		Age int `json:"age" minimum:"0" maximum:"150"`
+	}{}, `{"type":"object","properties":{"age":{"type":"integer","minimum":0,"maximum":150}},"required":["age"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Name string `json:"name" minimum:"1"`
+	}{}, "minimum")
+}
+
+func TestGenerateSchemaStringLength(t *testing.T) {
+	assertSchema(t, struct {
+		Code string `json:"code" minLength:"2" maxLength:"8"`
+	}{}, `{"type":"object","properties":{"code":{"type":"string","minLength":2,"maxLength":8}},"required":["code"],"additionalProperties":false}`)
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "not a string", value: struct {
+			Count int `json:"count" minLength:"1"`
+		}{}},
+		{name: "negative", value: struct {
+			Code string `json:"code" maxLength:"-1"`
+		}{}},
+		{name: "not a number", value: struct {
+			Code string `json:"code" minLength:"two"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateSchema(tt.value); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaPattern(t *testing.T) {
+	assertSchema(t, struct {
+		Currency string `json:"currency" pattern:"^[A-Z]{3}$"`
+	}{}, `{"type":"object","properties":{"currency":{"type":"string","pattern":"^[A-Z]{3}$"}},"required":["currency"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Currency string `json:"currency" pattern:"^[A-Z"`
+	}{}, "invalid 'pattern' tag on field 'Currency'")
+}
+
+func TestGenerateSchemaArrayConstraints(t *testing.T) {
+	assertSchema(t, struct {
+		Tags []string `json:"tags" minItems:"1" maxItems:"10" uniqueItems:"true"`
+	}{}, `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"},"minItems":1,"maxItems":10,"uniqueItems":true}},"required":["tags"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Tags []string `json:"tags" uniqueItems:"yes"`
+	}{}, "uniqueItems")
+	assertSchemaError(t, struct {
+		Name string `json:"name" minItems:"1"`
+	}{}, "minItems")
+}
+
+func TestGenerateSchemaDefault(t *testing.T) {
+	assertSchema(t, struct {
+		Limit int    `json:"limit" default:"10"`
+		Unit  string `json:"unit" default:"celsius"`
+	}{}, `{"type":"object","properties":{"limit":{"type":"integer","default":10},"unit":{"type":"string","default":"celsius"}},"required":["limit","unit"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Limit int `json:"limit" default:"ten"`
+	}{}, "invalid 'default' tag on field 'Limit'")
+}
+
+func TestGenerateSchemaTitle(t *testing.T) {
+	def, err := GenerateSchema(struct {
+		City string `json:"city" title:"City name"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"title":"City name"`) {
+		t.Errorf("schema lacks the title: %s", data)
+	}
+}
+
+func TestGenerateSchemaEnums(t *testing.T) {
+	assertSchema(t, struct {
+		Unit  string `json:"unit" enum:"celsius,fahrenheit"`
+		Level int    `json:"level" enum:"1,2,3"`
+	}{}, `{"type":"object","properties":{
+		"unit":{"type":"string","enum":["celsius","fahrenheit"]},
+		"level":{"type":"integer","enum":[1,2,3]}},
+		"required":["unit","level"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Level int `json:"level" enum:"1,two"`
+	}{}, "invalid 'enum' tag on field 'Level'")
+}
+
+type schemaNode struct {
+	Name     string        `json:"name"`
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+type schemaList struct {
+	Value int         `json:"value"`
+	Next  *schemaList `json:"next"`
+}
+
+func TestGenerateSchemaRecursive(t *testing.T) {
+	def, err := GenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	node := `{"type":"object","properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#/$defs/schemaNode"}}},"required":["name"],"additionalProperties":false}`
+	assertJSON(t, def, `{"type":"object","properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#/$defs/schemaNode"}}},"required":["name"],"additionalProperties":false,
+		"$defs":{"schemaNode":`+node+`}}`)
+	if err := ValidateDefinition(def); err != nil {
+		t.Errorf("ValidateDefinition: %v", err)
+	}
+}
+
+type schemaBaseModel struct {
+	ID string `json:"id"`
+}
+
+type schemaUserModel struct {
+	schemaBaseModel
+	Name string `json:"name"`
+}
+
+type schemaOwned struct {
+	Owner *schemaOwner `json:"owner,omitempty"`
+}
+
+type schemaOwner struct {
+	schemaOwned
+	X int `json:"x"`
+}
+
+type schemaSelfEmbedding struct {
+	*schemaSelfEmbedding
+	X int `json:"x"`
+}
+
+func TestGenerateSchemaEmbedded(t *testing.T) {
+	assertSchema(t, schemaUserModel{}, `{"type":"object","properties":{"id":{"type":"string"},"name":{"type":"string"}},"required":["id","name"],"additionalProperties":false}`)
+
+	assertSchema(t, struct {
+		schemaBaseModel
+		ID int `json:"id"`
+	}{}, `{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"],"additionalProperties":false}`)
+
+	assertSchemaError(t, schemaSelfEmbedding{}, "recursive embedded struct")
+}
+
+func TestGenerateSchemaEmbeddedRecursion(t *testing.T) {
+	def, err := GenerateSchema(schemaOwned{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if err := ValidateArgs(def, json.RawMessage(`{"owner":{"x":1,"owner":{"x":2}}}`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+}
+
+func TestGenerateSchemaNullablePointers(t *testing.T) {
+	type args struct {
+		Name     string  `json:"name"`
+		Nickname *string `json:"nickname"`
+	}
+	def, err := GenerateSchemaWithOptions(args{}, SchemaOptions{NullablePointers: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def, `{"type":"object","properties":{"name":{"type":"string"},"nickname":{"type":["string","null"]}},"required":["name","nickname"],"additionalProperties":false}`)
+
+	def, err = GenerateSchemaWithOptions(schemaList{}, SchemaOptions{NullablePointers: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def.Properties["next"], `{"anyOf":[{"$ref":"#/$defs/schemaList"},{"type":"null"}]}`)
+	if err := ValidateArgs(def, json.RawMessage(`{"value":1,"next":{"value":2,"next":null}}`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+
+	def, err = GenerateSchemaWithOptions(struct {
+		Unit *string `json:"unit" enum:"c,f"`
+	}{}, SchemaOptions{NullablePointers: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def.Properties["unit"], `{"type":["string","null"],"enum":["c","f",null]}`)
+}
+
+func TestGenerateSchemaJSONTagOptions(t *testing.T) {
+	assertSchema(t, struct {
+		Count int    `json:"count,string"`
+		Note  string `json:"note,omitempty"`
+		Skip  string `json:"-"`
+		Dash  string `json:"-,"`
+	}{}, `{"type":"object","properties":{"count":{"type":"string"},"note":{"type":"string"},"-":{"type":"string"}},"required":["count","-"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaForType(t *testing.T) {
+	fromType, err := GenerateSchemaForType(reflect.TypeOf(schemaNode{}))
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType: %v", err)
+	}
+	fromValue, err := GenerateSchema(&schemaNode{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if !reflect.DeepEqual(fromType, fromValue) {
+		t.Errorf("GenerateSchemaForType = %v, want %v", fromType, fromValue)
+	}
+}
+
+// TestMarshalJSONConcurrent marshals one shared definition from many goroutines; run it with -race.
+func TestMarshalJSONConcurrent(t *testing.T) {
+	def, err := GenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	def.Properties["empty"] = Definition{Type: Object}
+	want, err := json.Marshal(def)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := json.Marshal(def)
+			if err != nil || string(got) != string(want) {
+				t.Errorf("concurrent marshal = %s, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if def.Properties["empty"].Properties != nil {
+		t.Error("MarshalJSON modified the definition")
+	}
+}
+
+func TestMarshalJSONProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+		want string
+	}{
+		{name: "object", def: Definition{Type: Object}, want: `{"type":"object","properties":{}}`},
+		{name: "string", def: Definition{Type: String}, want: `{"type":"string"}`},
+		{name: "array", def: Definition{Type: Array, Items: &Definition{Type: Integer}}, want: `{"type":"array","items":{"type":"integer"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSON(t, tt.def, tt.want)
+		})
+	}
+}
+
+type schemaShape interface {
+	JSONSchema() *Definition
+}
+
+type schemaCircle struct{}
+
+func (schemaCircle) JSONSchema() *Definition {
+	return &Definition{Type: String, Enum: []any{"circle"}}
+}
+
+var schemaSharedPoint = &Definition{Type: Object, Properties: map[string]Definition{"x": {Type: Number}}}
+
+type schemaPoint struct{}
+
+func (schemaPoint) JSONSchema() *Definition {
+	return schemaSharedPoint
+}
+
+func TestGenerateSchemaProvider(t *testing.T) {
+	assertSchema(t, struct {
+		Shape schemaCircle  `json:"shape"`
+		Ptr   *schemaCircle `json:"ptr"`
+	}{}, `{"type":"object","properties":{"shape":{"type":"string","enum":["circle"]},"ptr":{"type":"string","enum":["circle"]}},"required":["shape","ptr"],"additionalProperties":false}`)
+
+	// An interface listing JSONSchema has no value to call it on and is rejected like any other interface.
+	_, err := GenerateSchema(struct {
+		Shape schemaShape `json:"shape"`
+	}{})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestGenerateSchemaBytes(t *testing.T) {
+	type payload struct {
+		Raw  json.RawMessage `json:"raw"`
+		Data []byte          `json:"data"`
+	}
+	assertSchema(t, payload{}, `{"type":"object","properties":{"raw":{},"data":{"type":"string","contentEncoding":"base64"}},"required":["raw","data"],"additionalProperties":false}`)
+
+	// The schema matches what encoding/json writes.
+	def, err := GenerateSchema(payload{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	data, err := json.Marshal(payload{Raw: json.RawMessage(`[1,{"a":true}]`), Data: []byte("hi")})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := ValidateArgs(def, data); err != nil {
+		t.Errorf("ValidateArgs(%s): %v", data, err)
+	}
+}
+
+func TestGenerateSchemaInterfaces(t *testing.T) {
+	assertSchema(t, struct {
+		Payload any `json:"payload"`
+	}{}, `{"type":"object","properties":{"payload":{}},"required":["payload"],"additionalProperties":false}`)
+
+	_, err := GenerateSchema(struct {
+		Reader io.Reader `json:"reader"`
+	}{})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	def := &Definition{
+		Type: Object,
+		Properties: map[string]Definition{
+			"city":  {Type: String, MinLength: clonePointer(new(int))},
+			"unit":  {Type: String, Enum: []any{"c", "f"}},
+			"days":  {Type: Integer, Minimum: new(float64)},
+			"tags":  {Type: Array, Items: &Definition{Type: String}, MaxItems: clonePointer(new(int))},
+			"owner": {Ref: "#/$defs/Owner"},
+		},
+		Required:             []string{"city"},
+		AdditionalProperties: AllowAdditionalProperties(false),
+		Defs:                 map[string]Definition{"Owner": {Type: Object, Properties: map[string]Definition{"name": {Type: String}}, Required: []string{"name"}}},
+	}
+	tests := []struct {
+		name     string
+		args     string
+		wantPath string
+	}{
+		{name: "valid", args: `{"city":"Paris","unit":"c","days":1}`},
+		{name: "empty arguments", args: ``, wantPath: "city"},
+		{name: "missing required", args: `{}`, wantPath: "city"},
+		{name: "wrong type", args: `{"city":1}`, wantPath: "city"},
+		{name: "enum", args: `{"city":"Paris","unit":"k"}`, wantPath: "unit"},
+		{name: "minimum", args: `{"city":"Paris","days":-1}`, wantPath: "days"},
+		{name: "max items", args: `{"city":"Paris","tags":["a"]}`, wantPath: "tags"},
+		{name: "additional property", args: `{"city":"Paris","extra":true}`, wantPath: "extra"},
+		{name: "reference", args: `{"city":"Paris","owner":{}}`, wantPath: "owner.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArgs(def, json.RawMessage(tt.args))
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Errorf("ValidateArgs: %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Path != tt.wantPath {
+				t.Errorf("ValidateArgs error = %v, want a violation at %q", err, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestValidateArgsEnumNull(t *testing.T) {
+	def := &Definition{Type: Object, Properties: map[string]Definition{
+		"untyped":  {Enum: []any{"a"}},
+		"nullable": {Type: String, Enum: []any{"a", nil}, Nullable: true},
+		"open":     {},
+	}}
+	if err := ValidateArgs(def, json.RawMessage(`{"nullable":null,"open":null}`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+	var validationErr *ValidationError
+	if err := ValidateArgs(def, json.RawMessage(`{"untyped":null}`)); !errors.As(err, &validationErr) || validationErr.Path != "untyped" {
+		t.Errorf("ValidateArgs error = %v, want null rejected by the enum", err)
+	}
+}
+
+func TestValidateArgsFirstViolation(t *testing.T) {
+	def := &Definition{
+		Type: Object,
+		Properties: map[string]Definition{
+			"b": {Type: String},
+			"a": {Type: String},
+			"c": {Type: String},
+		},
+		AdditionalProperties: AllowAdditionalProperties(false),
+	}
+	tests := []struct {
+		args     string
+		wantPath string
+	}{
+		{args: `{"c":1,"b":2,"a":3}`, wantPath: "a"},
+		{args: `{"z":1,"y":2,"x":3}`, wantPath: "x"},
+		{args: `{"x":1,"c":2}`, wantPath: "c"},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			var validationErr *ValidationError
+			if err := ValidateArgs(def, json.RawMessage(tt.args)); !errors.As(err, &validationErr) || validationErr.Path != tt.wantPath {
+				t.Fatalf("ValidateArgs(%s) error = %v, want a violation at %q", tt.args, err, tt.wantPath)
+			}
+		}
+	}
+}
+
+func TestStrictSchema(t *testing.T) {
+	type args struct {
+		City string      `json:"city"`
+		Unit string      `json:"unit,omitempty" enum:"c,f"`
+		Tree *schemaNode `json:"tree,omitempty"`
+	}
+	loose, err := GenerateSchema(args{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	strict, err := StrictSchema(args{})
+	if err != nil {
+		t.Fatalf("StrictSchema: %v", err)
+	}
+	if !reflect.DeepEqual(loose.Required, []string{"city"}) {
+		t.Errorf("loose required = %v", loose.Required)
+	}
+	if !reflect.DeepEqual(strict.Required, []string{"city", "tree", "unit"}) {
+		t.Errorf("strict required = %v, want every property", strict.Required)
+	}
+	assertJSON(t, strict.Properties["unit"], `{"type":["string","null"],"enum":["c","f",null]}`)
+	assertJSON(t, strict.Defs["schemaNode"].Properties["children"], `{"type":["array","null"],"items":{"$ref":"#/$defs/schemaNode"}}`)
+	if err := ValidateArgs(strict, json.RawMessage(`{"city":"Paris","unit":null,"tree":{"name":"a","children":[{"name":"b","children":null}]}}`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+}
+
+func TestStrictSchemaNullableReference(t *testing.T) {
+	def, err := StrictSchema(struct {
+		Next *schemaChain `json:"next,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatalf("StrictSchema: %v", err)
+	}
+	assertJSON(t, def.Defs["schemaChain"].Properties["next"], `{"anyOf":[{"$ref":"#/$defs/schemaChain"},{"type":"null"}]}`)
+}
+
+func TestStrictSchemaDoesNotModifyProviders(t *testing.T) {
+	if _, err := StrictSchema(schemaPoint{}); err != nil {
+		t.Fatalf("StrictSchema: %v", err)
+	}
+	if schemaSharedPoint.Properties["x"].Nullable || len(schemaSharedPoint.Required) > 0 {
+		t.Errorf("StrictSchema modified the provider's definition: %v", schemaSharedPoint)
+	}
+}
+
+type schemaChain struct {
+	Value int          `json:"value"`
+	Next  *schemaChain `json:"next,omitempty"`
+}
+
+func TestGenerateSchemaDraft(t *testing.T) {
+	tests := []struct {
+		draft   SchemaDraft
+		keyword string
+		other   string
+	}{
+		{draft: Draft202012, keyword: "$defs", other: "definitions"},
+		{draft: Draft07, keyword: "definitions", other: "$defs"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.draft), func(t *testing.T) {
+			def, err := GenerateSchemaWithOptions(schemaList{}, SchemaOptions{Draft: tt.draft})
+			if err != nil {
+				t.Fatalf("GenerateSchemaWithOptions: %v", err)
+			}
+			data, err := json.Marshal(def)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if !strings.Contains(string(data), `"`+tt.keyword+`":{"schemaList"`) || !strings.Contains(string(data), `"$ref":"#/`+tt.keyword+`/schemaList"`) {
+				t.Errorf("schema does not use %s: %s", tt.keyword, data)
+			}
+			if strings.Contains(string(data), tt.other) {
+				t.Errorf("schema mentions %s: %s", tt.other, data)
+			}
+		})
+	}
+}
+
+func TestParseDefinition(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(t *testing.T, def *Definition)
+	}{
+		{
+			name:  "object",
+			input: `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"}}},"required":["tags"],"additionalProperties":false}`,
+			check: func(t *testing.T, def *Definition) {
+				if def.Properties["tags"].Items.Type != String || !def.AdditionalProperties.forbidden() {
+					t.Errorf("parsed = %v", def)
+				}
+			},
+		},
+		{
+			name:  "map",
+			input: `{"type":"object","additionalProperties":{"type":"integer"}}`,
+			check: func(t *testing.T, def *Definition) {
+				if !def.describesMap() || def.AdditionalProperties.Schema.Type != Integer {
+					t.Errorf("parsed = %v", def)
+				}
+			},
+		},
+		{
+			name:  "nullable",
+			input: `{"type":["string","null"]}`,
+			check: func(t *testing.T, def *Definition) {
+				if def.Type != String || !def.Nullable {
+					t.Errorf("type = %q, nullable = %t", def.Type, def.Nullable)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := ParseDefinition([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ParseDefinition: %v", err)
+			}
+			tt.check(t, def)
+			assertJSON(t, def, tt.input)
+		})
+	}
+
+	if _, err := ParseDefinition([]byte(`{"type":`)); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+func TestComposition(t *testing.T) {
+	def := NewOneOf(Definition{Type: String}, Definition{Type: Object, Properties: map[string]Definition{"id": {Type: Integer}}})
+	assertJSON(t, def, `{"oneOf":[{"type":"string"},{"type":"object","properties":{"id":{"type":"integer"}}}]}`)
+	assertJSON(t, NewAnyOf(Definition{Type: String}), `{"anyOf":[{"type":"string"}]}`)
+	assertJSON(t, NewAllOf(Definition{Type: String}), `{"allOf":[{"type":"string"}]}`)
+
+	if err := ValidateArgs(def, json.RawMessage(`"a"`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+	if err := ValidateArgs(def, json.RawMessage(`1`)); err == nil {
+		t.Error("expected a value matching no subschema to fail")
+	}
+}
+
+type schemaStatus int
+
+func TestRegisterEnum(t *testing.T) {
+	if err := RegisterEnum(reflect.TypeOf(schemaStatus(0)), []any{0, 1}, []string{"Active", "Archived"}); err != nil {
+		t.Fatalf("RegisterEnum: %v", err)
+	}
+	assertSchema(t, struct {
+		Status schemaStatus `json:"status"`
+	}{}, `{"type":"object","properties":{"status":{"type":"integer","enum":[0,1],"x-enum-varnames":["Active","Archived"]}},"required":["status"],"additionalProperties":false}`)
+
+	if err := RegisterEnum(reflect.TypeOf(schemaStatus(0)), []any{0}, []string{"A", "B"}); err == nil {
+		t.Error("expected an error for mismatched names")
+	}
+}
+
+var schemaSharedTags = &Definition{Type: Array, Items: &Definition{Type: String}}
+
+type schemaTags []string
+
+func (schemaTags) JSONSchema() *Definition {
+	return schemaSharedTags
+}
+
+func TestGenerateSchemaDescriptionItems(t *testing.T) {
+	assertSchema(t, struct {
+		Tags []string `json:"tags" description:"list" descriptionItems:"a tag"`
+	}{}, `{"type":"object","properties":{"tags":{"type":"array","description":"list","items":{"type":"string","description":"a tag"}}},"required":["tags"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Name string `json:"name" descriptionItems:"a tag"`
+	}{}, "descriptionItems")
+
+	def, err := GenerateSchema(struct {
+		Tags schemaTags `json:"tags" descriptionItems:"a tag"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	assertJSON(t, def.Properties["tags"], `{"type":"array","items":{"type":"string","description":"a tag"}}`)
+	if schemaSharedTags.Items.Description != "" {
+		t.Errorf("the provider's definition was modified: %v", schemaSharedTags)
+	}
+}
+
+func TestCachedGenerateSchema(t *testing.T) {
+	first, err := CachedGenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("CachedGenerateSchema: %v", err)
+	}
+	second, err := CachedGenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("CachedGenerateSchema: %v", err)
+	}
+	if first != second {
+		t.Error("expected the cached definition to be reused")
+	}
+	want, err := GenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("cached = %v, want %v", first, want)
+	}
+}
+
+func BenchmarkGenerateSchema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateSchema(schemaNode{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCachedGenerateSchema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := CachedGenerateSchema(schemaNode{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateSchemaExclusiveBounds(t *testing.T) {
+	assertSchema(t, struct {
+		Ratio float64 `json:"ratio" exclusiveMinimum:"0" exclusiveMaximum:"1"`
+	}{}, `{"type":"object","properties":{"ratio":{"type":"number","exclusiveMinimum":0,"exclusiveMaximum":1}},"required":["ratio"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Ratio float64 `json:"ratio" minimum:"0" exclusiveMinimum:"0"`
+	}{}, "cannot define both 'minimum' and 'exclusiveMinimum'")
+}
+
+func TestGenerateSchemaMultipleOf(t *testing.T) {
+	assertSchema(t, struct {
+		Price float64 `json:"price" multipleOf:"0.01"`
+	}{}, `{"type":"object","properties":{"price":{"type":"number","multipleOf":0.01}},"required":["price"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Price float64 `json:"price" multipleOf:"0"`
+	}{}, "must be greater than 0")
+}
+
+func TestGenerateSchemaExamples(t *testing.T) {
+	assertSchema(t, struct {
+		Country string `json:"country" examples:"US,CA,MX"`
+		Limit   int    `json:"limit" examples:"[10, 20]"`
+	}{}, `{"type":"object","properties":{"country":{"type":"string","examples":["US","CA","MX"]},"limit":{"type":"integer","examples":[10,20]}},"required":["country","limit"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaDeprecated(t *testing.T) {
+	assertSchema(t, struct {
+		Legacy string `json:"legacy" deprecated:"true"`
+	}{}, `{"type":"object","properties":{"legacy":{"type":"string","deprecated":true}},"required":["legacy"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaConst(t *testing.T) {
+	assertSchema(t, struct {
+		Kind   string `json:"kind" const:"circle"`
+		Radius int    `json:"radius,omitempty"`
+	}{}, `{"type":"object","properties":{"kind":{"type":"string","const":"circle"},"radius":{"type":"integer"}},"required":["kind"],"additionalProperties":false}`)
+}
+
+func TestValidateArgsConst(t *testing.T) {
+	object := func(kind Definition) *Definition {
+		return &Definition{Type: Object, Properties: map[string]Definition{"kind": kind}}
+	}
+	tests := []struct {
+		name    string
+		def     *Definition
+		args    string
+		wantErr bool
+	}{
+		{name: "match", def: object(Definition{Const: "card"}), args: `{"kind":"card"}`},
+		{name: "mismatch", def: object(Definition{Const: "card"}), args: `{"kind":"cash"}`, wantErr: true},
+		{name: "untyped null", def: object(Definition{Const: "card"}), args: `{"kind":null}`, wantErr: true},
+		{name: "typed null", def: object(Definition{Type: String, Const: "card"}), args: `{"kind":null}`, wantErr: true},
+		{name: "nullable null", def: object(Definition{Type: String, Const: "card", Nullable: true}), args: `{"kind":null}`},
+		{
+			// A null discriminator must not select the then branch.
+			name: "discriminator",
+			def: &Definition{
+				Type: Object,
+				If:   &Definition{Properties: map[string]Definition{"kind": {Const: "card"}}, Required: []string{"kind"}},
+				Then: &Definition{Required: []string{"number"}},
+			},
+			args: `{"kind":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateArgs(tt.def, json.RawMessage(tt.args)); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArgs(%s) error = %v, wantErr %t", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaAdditionalProperties(t *testing.T) {
+	assertSchema(t, struct {
+		User  schemaUser            `json:"user"`
+		Users map[string]schemaUser `json:"users"`
+	}{}, `{"type":"object","properties":{
+		"user":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false},
+		"users":{"type":"object","additionalProperties":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}}},
+		"required":["user","users"],"additionalProperties":false}`)
+}
+
+type schemaUser struct {
+	Name string `json:"name"`
+}
+
+func TestGenerateSchemaHiddenFields(t *testing.T) {
+	type args struct {
+		Name   string `json:"name"`
+		Secret string `json:"secret" schema:"-"`
+	}
+	assertSchema(t, args{}, `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`)
+
+	data, err := json.Marshal(args{Name: "a", Secret: "b"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"name":"a","secret":"b"}` {
+		t.Errorf("hidden field is not serialized: %s", data)
+	}
+}
+
+func TestGenerateSchemaUnsigned(t *testing.T) {
+	assertSchema(t, struct {
+		Quantity uint  `json:"quantity"`
+		Offset   uint8 `json:"offset" minimum:"5"`
+	}{}, `{"type":"object","properties":{"quantity":{"type":"integer","minimum":0},"offset":{"type":"integer","minimum":5}},"required":["quantity","offset"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaNilInputs(t *testing.T) {
+	calls := map[string]func() (*Definition, error){
+		"GenerateSchema":            func() (*Definition, error) { return GenerateSchema(nil) },
+		"GenerateSchemaForType":     func() (*Definition, error) { return GenerateSchemaForType(nil) },
+		"GenerateSchemaWithOptions": func() (*Definition, error) { return GenerateSchemaWithOptions(nil, SchemaOptions{}) },
+		"CachedGenerateSchema":      func() (*Definition, error) { return CachedGenerateSchema(nil) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if _, err := call(); err == nil || !strings.Contains(err.Error(), "nil value") {
+				t.Fatalf("error = %v, want one for the nil value", err)
+			}
+		})
+	}
+}
+
+type schemaEmail string
+
+func TestRegisterFormat(t *testing.T) {
+	if err := RegisterFormat(reflect.TypeOf(schemaEmail("")), "email"); err != nil {
+		t.Fatalf("RegisterFormat: %v", err)
+	}
+	assertSchema(t, struct {
+		Email  schemaEmail `json:"email"`
+		Backup schemaEmail `json:"backup" format:"idn-email"`
+	}{}, `{"type":"object","properties":{"email":{"type":"string","format":"email"},"backup":{"type":"string","format":"idn-email"}},"required":["email","backup"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaOrder(t *testing.T) {
+	type args struct {
+		Zeta  string `json:"zeta"`
+		Alpha string `json:"alpha"`
+		Mid   string `json:"mid"`
+	}
+	def, err := GenerateSchema(args{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if !reflect.DeepEqual(def.Required, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("required = %v, want declaration order", def.Required)
+	}
+	if def.PropertyOrder != nil {
+		t.Errorf("x-order = %v without PropertyOrder", def.PropertyOrder)
+	}
+
+	def, err = GenerateSchemaWithOptions(args{}, SchemaOptions{PropertyOrder: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	if !reflect.DeepEqual(def.PropertyOrder, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("x-order = %v, want declaration order", def.PropertyOrder)
+	}
+}
+
+func TestGenerateSchemaInlineStruct(t *testing.T) {
+	assertSchema(t, struct {
+		Address struct {
+			City string `json:"city" description:"city"`
+		} `json:"address" description:"Postal address"`
+	}{}, `{"type":"object","properties":{"address":{"type":"object","description":"Postal address",
+		"properties":{"city":{"type":"string","description":"city"}},"required":["city"],"additionalProperties":false}},
+		"required":["address"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaOpenStruct(t *testing.T) {
+	assertSchema(t, struct {
+		Open   schemaUser `json:"open" additionalProperties:"true"`
+		Closed schemaUser `json:"closed"`
+	}{}, `{"type":"object","properties":{
+		"open":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]},
+		"closed":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}},
+		"required":["open","closed"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Name string `json:"name" additionalProperties:"true"`
+	}{}, "requires a struct type")
+}
+
+func TestGenerateSchemaErrorPath(t *testing.T) {
+	type settings struct {
+		Notifier chan string `json:"notifier"`
+	}
+	type user struct {
+		Settings settings `json:"settings"`
+	}
+	_, err := GenerateSchema(user{})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("error = %v, want a *SchemaError wrapping ErrUnsupportedType", err)
+	}
+	if schemaErr.Path != "user.Settings.Notifier" || schemaErr.Kind != reflect.Chan {
+		t.Errorf("path = %q, kind = %s", schemaErr.Path, schemaErr.Kind)
+	}
+	if !strings.Contains(err.Error(), "user.Settings.Notifier") {
+		t.Errorf("error %q does not name the field path", err)
+	}
+}
+
+func TestGenerateSchemaJSONNumber(t *testing.T) {
+	assertSchema(t, struct {
+		Amount json.Number `json:"amount"`
+	}{}, `{"type":"object","properties":{"amount":{"type":"number"}},"required":["amount"],"additionalProperties":false}`)
+}
+
+// assertCompiles fails the test unless source, generated by GenerateGoStruct, type-checks as a package.
+func assertCompiles(t *testing.T, source string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", "package generated\n\n"+source, 0)
+	if err == nil {
+		_, err = new(types.Config).Check("generated", fset, []*ast.File{file}, nil)
+	}
+	if err != nil {
+		t.Errorf("generated source does not compile: %v\n%s", err, source)
+	}
+}
+
+func TestGenerateGoStruct(t *testing.T) {
+	def, err := GenerateSchema(struct {
+		Name     string        `json:"name" description:"Display name"`
+		Unit     string        `json:"unit,omitempty" enum:"c,f"`
+		Tags     []string      `json:"tags,omitempty"`
+		Children []*schemaNode `json:"children,omitempty"`
+		Meta     struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	source, err := def.GenerateGoStruct("Args")
+	if err != nil {
+		t.Fatalf("GenerateGoStruct: %v", err)
+	}
+	for _, want := range []string{"type Args struct", "type ArgsMeta struct", "type SchemaNode struct", `enum:"c,f"`} {
+		if !strings.Contains(source, want) {
+			t.Errorf("generated source lacks %q:\n%s", want, source)
+		}
+	}
+	assertCompiles(t, source)
+
+	if _, err := def.GenerateGoStruct("args"); err == nil {
+		t.Error("expected an error for an unexported type name")
+	}
+	if _, err := (&Definition{Type: String}).GenerateGoStruct("Args"); err == nil {
+		t.Error("expected an error for a non-object schema")
+	}
+}
+
+func TestGenerateGoStructRecursiveRoot(t *testing.T) {
+	def, err := GenerateSchema(schemaNode{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	source, err := def.GenerateGoStruct("Node")
+	if err != nil {
+		t.Fatalf("GenerateGoStruct: %v", err)
+	}
+	assertCompiles(t, source)
+	if strings.Count(source, "struct {") != 1 || !strings.Contains(source, "[]Node") {
+		t.Errorf("expected a single self-referencing struct:\n%s", source)
+	}
+}
+
+func TestValidateDefinition(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     Definition
+		wantErr bool
+	}{
+		{name: "valid", def: Definition{Type: Object, Properties: map[string]Definition{"a": {Type: String}}, Required: []string{"a"}}},
+		{name: "missing required property", def: Definition{Type: Object, Required: []string{"a"}}, wantErr: true},
+		{name: "array without items", def: Definition{Type: Array}, wantErr: true},
+		{name: "null enum value", def: Definition{Type: String, Enum: []any{"a", nil}}, wantErr: true},
+		{name: "nullable enum", def: Definition{Type: String, Enum: []any{"a", nil}, Nullable: true}},
+		{name: "invalid shared definition", def: Definition{Ref: "#/$defs/A", Defs: map[string]Definition{"A": {Type: Array}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDefinition(&tt.def); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDefinition error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaContent(t *testing.T) {
+	assertSchema(t, struct {
+		Image string `json:"image" contentEncoding:"base64" contentMediaType:"image/png"`
+	}{}, `{"type":"object","properties":{"image":{"type":"string","contentEncoding":"base64","contentMediaType":"image/png"}},"required":["image"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Size int `json:"size" contentMediaType:"image/png"`
+	}{}, "contentMediaType")
+}
+
+type schemaCodes []string
+
+func TestGenerateSchemaItemsTags(t *testing.T) {
+	assertSchema(t, struct {
+		Codes schemaCodes `json:"codes" itemsMinLength:"2" itemsMaxLength:"3" itemsPattern:"^[A-Z]+$"`
+	}{}, `{"type":"object","properties":{"codes":{"type":"array","items":{"type":"string","minLength":2,"maxLength":3,"pattern":"^[A-Z]+$"}}},"required":["codes"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Code string `json:"code" itemsMinLength:"2"`
+	}{}, "items*")
+}
+
+func TestClone(t *testing.T) {
+	original := &Definition{
+		Type:       Object,
+		Properties: map[string]Definition{"tags": {Type: Array, Items: &Definition{Type: String}}},
+		Required:   []string{"tags"},
+		Enum:       []any{map[string]any{"a": 1}},
+	}
+	clone := original.Clone()
+	clone.Properties["extra"] = Definition{Type: String}
+	clone.Properties["tags"].Items.Description = "changed"
+	clone.Required[0] = "changed"
+	clone.Enum[0].(map[string]any)["a"] = 2
+	assertJSON(t, original, `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"}}},"required":["tags"],"enum":[{"a":1}]}`)
+}
+
+func TestGenerateSchemaReadWriteOnly(t *testing.T) {
+	assertSchema(t, struct {
+		CreatedAt time.Time `json:"createdAt" readOnly:"true"`
+		Password  string    `json:"password" writeOnly:"true"`
+	}{}, `{"type":"object","properties":{"createdAt":{"type":"string","format":"date-time","readOnly":true},"password":{"type":"string","writeOnly":true}},"required":["createdAt","password"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Token string `json:"token" readOnly:"true" writeOnly:"true"`
+	}{}, "cannot be both 'readOnly' and 'writeOnly'")
+}
+
+func TestGenerateSchemaTypeMapper(t *testing.T) {
+	def, err := GenerateSchemaWithOptions(struct {
+		ID    int64  `json:"id"`
+		Count int    `json:"count"`
+		Ref   *int64 `json:"ref,omitempty"`
+	}{}, SchemaOptions{TypeMapper: func(t reflect.Type) (DataType, bool) {
+		return String, t.Kind() == reflect.Int64
+	}})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def, `{"type":"object","properties":{"id":{"type":"string"},"count":{"type":"integer"},"ref":{"type":"string"}},"required":["id","count"],"additionalProperties":false}`)
+
+	for _, mapped := range []DataType{Array, Object, "text"} {
+		t.Run(string(mapped), func(t *testing.T) {
+			_, err := GenerateSchemaWithOptions(struct {
+				ID int64 `json:"id"`
+			}{}, SchemaOptions{TypeMapper: func(t reflect.Type) (DataType, bool) {
+				return mapped, t.Kind() == reflect.Int64
+			}})
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) || !strings.Contains(err.Error(), "field 'ID'") || !strings.Contains(err.Error(), "'"+string(mapped)+"'") {
+				t.Errorf("error = %v, want one naming the field and the mapped type", err)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaConflictingNames(t *testing.T) {
+	assertSchemaError(t, struct {
+		Alias string `json:"Name"`
+		Name  string
+	}{}, "fields 'Alias' and 'Name' both map to property 'Name'")
+}
+
+func TestMarshalJSONRaw(t *testing.T) {
+	def := Definition{Type: String, Raw: map[string]any{"x-foo": "bar", "type": "number"}}
+	assertJSON(t, def, `{"type":"string","x-foo":"bar"}`)
+	assertJSON(t, Definition{Raw: map[string]any{"x-foo": 1}}, `{"x-foo":1}`)
+}
+
+func TestGenerateSchemaRequiredTag(t *testing.T) {
+	assertSchema(t, struct {
+		Optional string `json:"optional" required:"false"`
+		Forced   string `json:"forced,omitempty" required:"true"`
+		Default  string `json:"default"`
+	}{}, `{"type":"object","properties":{"optional":{"type":"string"},"forced":{"type":"string"},"default":{"type":"string"}},"required":["forced","default"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaDocument(t *testing.T) {
+	def, err := GenerateSchemaDocument(schemaUser{}, SchemaOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaDocument: %v", err)
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, want := range []string{`"$schema":"https://json-schema.org/draft/2020-12/schema"`, `"title":"schemaUser"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("document lacks %s: %s", want, data)
+		}
+	}
+
+	def, err = GenerateSchemaDocument(&schemaUser{}, SchemaOptions{Draft: Draft07})
+	if err != nil {
+		t.Fatalf("GenerateSchemaDocument: %v", err)
+	}
+	if def.Schema != "http://json-schema.org/draft-07/schema#" || def.Title != "schemaUser" {
+		t.Errorf("$schema = %q, title = %q", def.Schema, def.Title)
+	}
+}
+
+type schemaKey string
+
+func (k schemaKey) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+func TestGenerateSchemaNonStringMapKeys(t *testing.T) {
+	def, err := GenerateSchemaWithOptions(struct {
+		Counts map[int]string `json:"counts"`
+	}{}, SchemaOptions{AllowNonStringMapKeys: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def.Properties["counts"], `{"type":"object","additionalProperties":{"type":"string"}}`)
+
+	if _, err := GenerateSchemaWithOptions(struct {
+		Counts map[float64]string `json:"counts"`
+	}{}, SchemaOptions{AllowNonStringMapKeys: true}); err == nil {
+		t.Error("expected an error for float keys, which encoding/json rejects")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := &Definition{Type: Object, Properties: map[string]Definition{"a": {Type: String}}, Required: []string{"a"}}
+	extra := &Definition{Type: Object, Properties: map[string]Definition{"b": {Type: Integer}}, Required: []string{"b", "a"}}
+	merged, err := base.Merge(extra)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	assertJSON(t, merged, `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}},"required":["a","b"]}`)
+	assertJSON(t, base, `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`)
+
+	conflict := &Definition{Type: Object, Properties: map[string]Definition{"a": {Type: Integer}}}
+	if _, err := base.Merge(conflict); err == nil {
+		t.Error("expected an error for conflicting property types")
+	}
+}
+
+func TestGenerateSchemaMaxDepth(t *testing.T) {
+	type leaf struct {
+		Value string `json:"value"`
+	}
+	type middle struct {
+		Leaf leaf `json:"leaf"`
+	}
+	type top struct {
+		Middle middle `json:"middle"`
+	}
+	if _, err := GenerateSchemaWithOptions(top{}, SchemaOptions{MaxDepth: 2}); err == nil || !strings.Contains(err.Error(), "depth") {
+		t.Errorf("error = %v, want the depth limit", err)
+	}
+	if _, err := GenerateSchemaWithOptions(top{}, SchemaOptions{MaxDepth: 3}); err != nil {
+		t.Errorf("GenerateSchemaWithOptions: %v", err)
+	}
+}
+
+func TestGenerateSchemaTypeTag(t *testing.T) {
+	assertSchema(t, struct {
+		At int64 `json:"at" type:"string" format:"date-time"`
+	}{}, `{"type":"object","properties":{"at":{"type":"string","format":"date-time"}},"required":["at"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		At int64 `json:"at" type:"text"`
+	}{}, "unknown type 'text'")
+
+	// Restating the reflected type keeps its items and properties.
+	assertSchema(t, struct {
+		Tags []string   `json:"tags" type:"array"`
+		User schemaUser `json:"user" type:"object"`
+	}{}, `{"type":"object","properties":{
+		"tags":{"type":"array","items":{"type":"string"}},
+		"user":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}},
+		"required":["tags","user"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		IDs string `json:"ids" type:"array"`
+	}{}, "invalid 'type' tag on field 'IDs': type 'array' requires a field of that kind")
+	assertSchemaError(t, struct {
+		Tags []string `json:"tags" type:"object"`
+	}{}, "invalid 'type' tag on field 'Tags': type 'object' requires a field of that kind")
+}
+
+func TestAnalyzeOptionality(t *testing.T) {
+	findings := AnalyzeOptionality(struct {
+		Count    int     `json:"count,omitempty"`
+		Enabled  bool    `json:"enabled,omitempty"`
+		Limit    *int    `json:"limit,omitempty"`
+		Name     string  `json:"name,omitempty"`
+		Required float64 `json:"required"`
+	}{})
+	want := []string{
+		"Count: optional int field cannot distinguish its zero value from an omitted one; use *int",
+		"Enabled: optional bool field cannot distinguish its zero value from an omitted one; use *bool",
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("findings = %q, want %q", findings, want)
+	}
+}
+
+func TestDiffDefinitions(t *testing.T) {
+	old := &Definition{Type: Object, Properties: map[string]Definition{"a": {Type: String}}}
+	updated := &Definition{Type: Object, Properties: map[string]Definition{"a": {Type: Integer}, "b": {Type: String}}}
+	var lines []string
+	for _, change := range DiffDefinitions(old, updated) {
+		lines = append(lines, change.String())
+	}
+	got := strings.Join(lines, "\n")
+	if len(lines) != 2 || !strings.Contains(got, "a") || !strings.Contains(got, "b") {
+		t.Errorf("changes =\n%s", got)
+	}
+	if changes := DiffDefinitions(old, old); len(changes) != 0 {
+		t.Errorf("changes between identical definitions = %v", changes)
+	}
+}
+
+func TestGenerateSchemaEnumDescriptions(t *testing.T) {
+	assertSchema(t, struct {
+		Priority string `json:"priority" enum:"low,high" enumDescriptions:"take it easy,act now"`
+	}{}, `{"type":"object","properties":{"priority":{"type":"string","enum":["low","high"],"x-enumDescriptions":["take it easy","act now"]}},"required":["priority"],"additionalProperties":false}`)
+
+	assertSchemaError(t, struct {
+		Priority string `json:"priority" enum:"low,high" enumDescriptions:"take it easy"`
+	}{}, "1 descriptions for 2 enum values")
+}
+
+func TestGenerateSchemaOptionalByDefault(t *testing.T) {
+	def, err := GenerateSchemaWithOptions(struct {
+		Name  string `json:"name"`
+		ID    string `json:"id" required:"true"`
+		Kind  string `json:"kind" const:"user"`
+		Email string `json:"email,omitempty"`
+	}{}, SchemaOptions{OptionalByDefault: true})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	if !reflect.DeepEqual(def.Required, []string{"id", "kind"}) {
+		t.Errorf("required = %v", def.Required)
+	}
+}
+
+func TestAdditionalProps(t *testing.T) {
+	tests := []struct {
+		name  string
+		props *AdditionalProps
+		json  string
+	}{
+		{name: "forbidden", props: AllowAdditionalProperties(false), json: `false`},
+		{name: "allowed", props: AllowAdditionalProperties(true), json: `true`},
+		{name: "schema", props: AdditionalPropertiesSchema(&Definition{Type: String}), json: `{"type":"string"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSON(t, tt.props, tt.json)
+			var parsed AdditionalProps
+			if err := json.Unmarshal([]byte(tt.json), &parsed); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(&parsed, tt.props) {
+				t.Errorf("parsed = %+v, want %+v", parsed, tt.props)
+			}
+		})
+	}
+
+	if _, err := NewAdditionalProps("yes"); err == nil {
+		t.Error("expected an error for a string")
+	}
+	both := &Definition{Type: Object, AdditionalProperties: &AdditionalProps{Bool: new(bool), Schema: &Definition{Type: String}}}
+	if err := ValidateDefinition(both); err == nil {
+		t.Error("expected an error for a boolean and a schema")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	def := &Definition{
+		Type:       Object,
+		Properties: map[string]Definition{"user": {Ref: "#/$defs/User", Description: "The owner"}},
+		Defs:       map[string]Definition{"User": {Type: Object, Description: "A user", Properties: map[string]Definition{"name": {Type: String}}}},
+	}
+	resolved, err := def.Resolve(nil)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	assertJSON(t, resolved, `{"type":"object","properties":{"user":{"type":"object","description":"The owner","properties":{"name":{"type":"string"}}}}}`)
+
+	if _, err := (&Definition{Ref: "#/$defs/Missing"}).Resolve(nil); err == nil {
+		t.Error("expected an error for an unresolved reference")
+	}
+	recursive, err := GenerateSchema(schemaList{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if _, err := recursive.Resolve(nil); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("Resolve error = %v, want a circular reference", err)
+	}
+}
+
+func TestGenerateSchemaLanguage(t *testing.T) {
+	type args struct {
+		City string `json:"city" description:"City" description_es:"Ciudad"`
+		Zip  string `json:"zip" description:"Postal code"`
+	}
+	def, err := GenerateSchemaWithOptions(args{}, SchemaOptions{Language: "es"})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def.Properties["city"], `{"type":"string","description":"Ciudad","x-descriptions":{"es":"Ciudad"}}`)
+	assertJSON(t, def.Properties["zip"], `{"type":"string","description":"Postal code"}`)
+
+	def, err = GenerateSchema(args{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	assertJSON(t, def.Properties["city"], `{"type":"string","description":"City","x-descriptions":{"es":"Ciudad"}}`)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	a := &Definition{Type: Object, Properties: map[string]Definition{"b": {Type: String}, "a": {Type: String}}, Required: []string{"b", "a"}}
+	b := &Definition{Type: Object, Properties: map[string]Definition{"a": {Type: String}, "b": {Type: String}}, Required: []string{"a", "b"}}
+	first, err := a.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON: %v", err)
+	}
+	again, err := a.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON: %v", err)
+	}
+	other, err := b.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON: %v", err)
+	}
+	if string(first) != string(again) || string(first) != string(other) {
+		t.Errorf("canonical forms differ:\n%s\n%s\n%s", first, again, other)
+	}
+	if !reflect.DeepEqual(a.Required, []string{"b", "a"}) {
+		t.Errorf("CanonicalJSON modified the receiver: %v", a.Required)
+	}
+}
+
+func TestOpenAIJSONSchemaRoundTrip(t *testing.T) {
+	def := &Definition{
+		Type:     Object,
+		Required: []string{"address"},
+		Properties: map[string]Definition{
+			"address": {Type: Object, Properties: map[string]Definition{"city": {Type: String, Description: "City"}}, Required: []string{"city"}},
+			"unit":    {Type: String, Enum: []any{"c", "f"}},
+			"tags":    {Type: Array, Items: &Definition{Type: String}},
+			"labels":  {Type: Object, AdditionalProperties: AdditionalPropertiesSchema(&Definition{Type: String})},
+		},
+		AdditionalProperties: AllowAdditionalProperties(false),
+	}
+	converted := def.ToOpenAIJSONSchema()
+	if converted.Properties["address"].Properties["city"].Type != jsonschema.String {
+		t.Errorf("converted = %+v", converted)
+	}
+	assertJSON(t, FromOpenAIJSONSchema(converted), `{"type":"object","required":["address"],"properties":{
+		"address":{"type":"object","properties":{"city":{"type":"string","description":"City"}},"required":["city"]},
+		"unit":{"type":"string","enum":["c","f"]},"tags":{"type":"array","items":{"type":"string"}},
+		"labels":{"type":"object","additionalProperties":{"type":"string"}}},
+		"additionalProperties":false}`)
+
+	nullable := &Definition{Type: String, Enum: []any{"a", nil}, Nullable: true}
+	if got := nullable.ToOpenAIJSONSchema().Enum; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("enum = %q, want the null member dropped", got)
+	}
+}
+
+func TestNoParams(t *testing.T) {
+	def := NoParams()
+	assertJSON(t, def, `{"type":"object","properties":{},"additionalProperties":false}`)
+	for _, args := range []string{``, `null`, `{}`} {
+		if err := ValidateArgs(def, json.RawMessage(args)); err != nil {
+			t.Errorf("ValidateArgs(%q): %v", args, err)
+		}
+	}
+}
+
+func TestSchemaForFunc(t *testing.T) {
+	want := `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`
+	tests := []struct {
+		name string
+		fn   any
+	}{
+		{name: "with context", fn: func(ctx context.Context, args schemaUser) (string, error) { return "", nil }},
+		{name: "pointer", fn: func(args *schemaUser) error { return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := SchemaForFunc(tt.fn)
+			if err != nil {
+				t.Fatalf("SchemaForFunc: %v", err)
+			}
+			assertJSON(t, def, want)
+		})
+	}
+
+	for name, fn := range map[string]any{
+		"nil":             nil,
+		"not a function":  42,
+		"two parameters":  func(a, b schemaUser) {},
+		"not a struct":    func(string) {},
+		"variadic":        func(...schemaUser) {},
+		"no parameters":   func() {},
+		"context pointer": func(*context.Context, schemaUser) {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := SchemaForFunc(fn); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTree(t *testing.T) {
+	def, err := GenerateSchema(struct {
+		Address struct {
+			City string `json:"city"`
+		} `json:"address"`
+		Tags []string `json:"tags,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	want := "object\n" +
+		"├── address: object (required)\n" +
+		"│   └── city: string (required)\n" +
+		"└── tags: array of string\n"
+	if got := def.Tree(); got != want {
+		t.Errorf("Tree() =\n%s\nwant\n%s", got, want)
+	}
+	if got := def.String(); !strings.Contains(got, "\n  \"type\": \"object\"") {
+		t.Errorf("String() = %s", got)
+	}
+}
+
+func TestGenerateSchemaPointerSlices(t *testing.T) {
+	user := `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`
+	assertSchema(t, struct {
+		Users  []*schemaUser `json:"users"`
+		Others *[]schemaUser `json:"others"`
+	}{}, `{"type":"object","properties":{
+		"users":{"type":"array","items":`+user+`},
+		"others":{"type":"array","items":`+user+`}},
+		"required":["users","others"],"additionalProperties":false}`)
+}
+
+func TestGenerateSchemaEmptyEnum(t *testing.T) {
+	for _, tag := range []string{`enum:""`, `enum:","`, `enum:" "`} {
+		t.Run(tag, func(t *testing.T) {
+			field := reflect.StructField{Name: "Unit", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`json:"unit" ` + tag)}
+			_, err := GenerateSchemaForType(reflect.StructOf([]reflect.StructField{field}))
+			if err == nil || !strings.Contains(err.Error(), "invalid 'enum' tag on field 'Unit'") {
+				t.Errorf("error = %v, want the empty enum tag", err)
+			}
+		})
+	}
+}
+
+func TestConditionalSchema(t *testing.T) {
+	def := &Definition{
+		Type:       Object,
+		Properties: map[string]Definition{"kind": {Type: String}, "number": {Type: String}},
+		If:         &Definition{Properties: map[string]Definition{"kind": {Const: "card"}}, Required: []string{"kind"}},
+		Then:       &Definition{Required: []string{"number"}},
+		Else:       &Definition{Properties: map[string]Definition{"number": {Type: Null}}},
+	}
+	assertJSON(t, def, `{"type":"object","properties":{"kind":{"type":"string"},"number":{"type":"string"}},
+		"if":{"properties":{"kind":{"const":"card"}},"required":["kind"]},
+		"then":{"required":["number"]},
+		"else":{"properties":{"number":{"type":"null"}}}}`)
+
+	if err := ValidateArgs(def, json.RawMessage(`{"kind":"cash"}`)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+	if err := ValidateArgs(def, json.RawMessage(`{"kind":"card"}`)); err == nil {
+		t.Error("expected the then branch to require number")
+	}
+	if err := ValidateDefinition(&Definition{If: &Definition{}, Then: &Definition{Type: Array}}); err == nil {
+		t.Error("expected an error for an invalid then subschema")
+	}
+}
+
+func TestGenerateSchemaSkipUnsupportedFields(t *testing.T) {
+	type config struct {
+		Name     string `json:"name"`
+		Callback func() `json:"callback"`
+	}
+	assertSchemaError(t, config{}, "Callback")
+
+	var warnings []string
+	def, err := GenerateSchemaWithOptions(config{}, SchemaOptions{SkipUnsupportedFields: true, OnSkippedField: func(warning string) {
+		warnings = append(warnings, warning)
+	}})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def, `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`)
+	if !reflect.DeepEqual(warnings, []string{"config.Callback: unsupported type: func"}) {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+type schemaPage[T any] struct {
+	Items []T            `json:"items"`
+	Next  *schemaPage[T] `json:"next,omitempty"`
+}
+
+func TestGenerateSchemaGenerics(t *testing.T) {
+	def, err := GenerateSchema(schemaPage[schemaUser]{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	page := `{"type":"object","properties":{"items":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}},
+		"next":{"$ref":"#/$defs/schemaPage[schemaUser]"}},"required":["items"],"additionalProperties":false}`
+	assertJSON(t, def, `{"type":"object","properties":{"items":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],"additionalProperties":false}},
+		"next":{"$ref":"#/$defs/schemaPage[schemaUser]"}},"required":["items"],"additionalProperties":false,
+		"$defs":{"schemaPage[schemaUser]":`+page+`}}`)
+}
+
+func TestGenerateSchemaGenericInstantiations(t *testing.T) {
+	// math/rand.Rand and math/rand/v2.Rand share a short name, as a.Item and b.Item would.
+	def, err := GenerateSchema(struct {
+		Users schemaPage[schemaUser]  `json:"users"`
+		V1    schemaPage[rand.Rand]   `json:"v1"`
+		V2    schemaPage[randv2.Rand] `json:"v2"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	if len(def.Defs) != 3 {
+		t.Fatalf("got %d definitions, want one per instantiation: %v", len(def.Defs), def)
+	}
+	if def.Properties["v1"].Properties["next"].Ref == def.Properties["v2"].Properties["next"].Ref {
+		t.Errorf("both instantiations reference %s", def.Properties["v1"].Properties["next"].Ref)
+	}
+	args := `{"users":{"items":[{"name":"a"}],"next":{"items":[{"name":"b"}]}},"v1":{"items":[{}],"next":{"items":[]}},"v2":{"items":[],"next":{"items":[{}]}}}`
+	if err := ValidateArgs(def, json.RawMessage(args)); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+}
+
+func TestGenerateSchemaDescriptions(t *testing.T) {
+	type args struct {
+		Address struct {
+			City string `json:"city" description:"City"`
+		} `json:"address"`
+		Tags []string `json:"tags"`
+	}
+	def, err := GenerateSchemaWithOptions(args{}, SchemaOptions{Descriptions: map[string]string{
+		"address.city": "Town",
+		"tags[]":       "A tag",
+	}})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def.Properties["address"].Properties["city"], `{"type":"string","description":"Town"}`)
+	assertJSON(t, def.Properties["tags"].Items, `{"type":"string","description":"A tag"}`)
+
+	if _, err := GenerateSchemaWithOptions(args{}, SchemaOptions{Descriptions: map[string]string{"address.town": "Town"}}); err == nil {
+		t.Error("expected an error for a path matching no property")
+	}
+}
+
+func TestMarshalJSONAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		def  Definition
+		want string
+	}{
+		{name: "string", def: Definition{Type: String, AdditionalProperties: AllowAdditionalProperties(false)}, want: `{"type":"string"}`},
+		{name: "array", def: Definition{Type: Array, Items: &Definition{Type: String}, AdditionalProperties: AllowAdditionalProperties(false)}, want: `{"type":"array","items":{"type":"string"}}`},
+		{name: "object", def: Definition{Type: Object, AdditionalProperties: AllowAdditionalProperties(false)}, want: `{"type":"object","properties":{},"additionalProperties":false}`},
+		{name: "untyped", def: Definition{AdditionalProperties: AllowAdditionalProperties(false)}, want: `{"additionalProperties":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSON(t, tt.def, tt.want)
+		})
+	}
+}
+
+func TestPaths(t *testing.T) {
+	def, err := GenerateSchema(struct {
+		Address struct {
+			City string `json:"city"`
+		} `json:"address"`
+		Tags   []string          `json:"tags,omitempty"`
+		Labels map[string]string `json:"labels,omitempty"`
+		Tree   *schemaNode       `json:"tree,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatalf("GenerateSchema: %v", err)
+	}
+	var paths []string
+	for _, p := range def.Paths() {
+		paths = append(paths, fmt.Sprintf("%s:%s:%t:%s", p.Path, p.Type, p.Required, p.Ref))
+	}
+	want := []string{
+		"address.city:string:true:",
+		"labels.*:string:false:",
+		"tags[]:string:false:",
+		"tree.children[].children[]:object:false:#/$defs/schemaNode",
+		"tree.children[].name:string:true:",
+		"tree.name:string:true:",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+}
+
+func TestGenerateSchemaTagName(t *testing.T) {
+	type base struct {
+		ID string `mapstructure:"id"`
+	}
+	def, err := GenerateSchemaWithOptions(struct {
+		base     `mapstructure:",squash"`
+		UserName string `mapstructure:"user_name"`
+		Nick     string `mapstructure:"nick,omitempty"`
+		Token    string `mapstructure:"-"`
+		Nested   base   `mapstructure:"nested"`
+	}{}, SchemaOptions{TagName: "mapstructure"})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithOptions: %v", err)
+	}
+	assertJSON(t, def, `{"type":"object","properties":{
+		"id":{"type":"string"},"user_name":{"type":"string"},"nick":{"type":"string"},
+		"nested":{"type":"object","properties":{"id":{"type":"string"}},"required":["id"],"additionalProperties":false}},
+		"required":["id","user_name","nested"],"additionalProperties":false}`)
+}
+
+func TestMarshalJSONComment(t *testing.T) {
+	assertJSON(t, Definition{Type: String, Comment: "kept for v1 clients"}, `{"type":"string","$comment":"kept for v1 clients"}`)
+	assertSchema(t, struct {
+		Legacy string `json:"legacy" comment:"kept for v1 clients"`
+	}{}, `{"type":"object","properties":{"legacy":{"type":"string","$comment":"kept for v1 clients"}},"required":["legacy"],"additionalProperties":false}`)
+}
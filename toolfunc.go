@@ -0,0 +1,59 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ToolFunc adapts a plain function into a Tool, which is handy for registering ad-hoc tools inline
+// without declaring a named type.
+type ToolFunc struct {
+	Def ToolDefinition                                               // Definition advertised to the model.
+	Fn  func(ctx context.Context, args json.RawMessage) (any, error) // Function invoked on each call.
+}
+
+// NewToolFunc creates a ToolFunc from a definition and the function that executes it.
+func NewToolFunc(def ToolDefinition, fn func(ctx context.Context, args json.RawMessage) (any, error)) *ToolFunc {
+	return &ToolFunc{Def: def, Fn: fn}
+}
+
+// GetDefinition returns the definition of the tool.
+func (t *ToolFunc) GetDefinition() ToolDefinition {
+	return t.Def
+}
+
+// Execute invokes the wrapped function with the raw JSON arguments.
+func (t *ToolFunc) Execute(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	if t.Fn == nil {
+		return nil, fmt.Errorf("tool %s has no function", t.Def.Name)
+	}
+	return t.Fn(ctx, args)
+}
+
+// SimpleTool is implemented by tools that cannot fail, such as pure functions, which would otherwise
+// have to return an always-nil error. Wrap one with NewSimpleTool to register it as a Tool.
+type SimpleTool interface {
+	GetDefinition() ToolDefinition
+	ExecuteSimple(args json.RawMessage) any
+}
+
+// simpleTool adapts a SimpleTool into a Tool.
+type simpleTool struct {
+	tool SimpleTool
+}
+
+// NewSimpleTool wraps a SimpleTool into a Tool whose Execute never returns an error.
+func NewSimpleTool(tool SimpleTool) Tool {
+	return &simpleTool{tool: tool}
+}
+
+// GetDefinition returns the definition of the wrapped tool.
+func (t *simpleTool) GetDefinition() ToolDefinition {
+	return t.tool.GetDefinition()
+}
+
+// Execute invokes the wrapped tool with the raw JSON arguments.
+func (t *simpleTool) Execute(_ context.Context, args json.RawMessage) (interface{}, error) {
+	return t.tool.ExecuteSimple(args), nil
+}
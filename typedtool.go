@@ -0,0 +1,153 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typedTool adapts a strongly typed function into a Tool.
+// The parameters schema is generated from the input type, and arguments are decoded into it before each call.
+type typedTool[In any, Out any] struct {
+	definition ToolDefinition
+	fn         func(context.Context, In) (Out, error)
+	buildError error
+}
+
+// NewTypedTool creates a Tool from a typed function. The parameters schema is generated from In
+// with GenerateRawSchema, and the JSON arguments of each call are unmarshalled into In before fn runs.
+// If the schema cannot be generated, the error is returned by every call to Execute.
+func NewTypedTool[In any, Out any](name, description string, fn func(context.Context, In) (Out, error)) Tool {
+	var zero In
+	schema, err := GenerateRawSchema(zero)
+	if err != nil {
+		err = fmt.Errorf("error generating schema for tool %s: %w", name, err)
+	}
+	return &typedTool[In, Out]{
+		definition: ToolDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  schema,
+		},
+		fn:         fn,
+		buildError: err,
+	}
+}
+
+// GetDefinition returns the tool definition generated from the input type.
+func (t *typedTool[In, Out]) GetDefinition() ToolDefinition {
+	return t.definition
+}
+
+// Execute decodes the arguments into the input type and invokes the wrapped function.
+func (t *typedTool[In, Out]) Execute(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	if t.buildError != nil {
+		return nil, t.buildError
+	}
+
+	in, err := DecodeArgs[In](args)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding arguments for tool %s: %w", t.definition.Name, err)
+	}
+	return t.fn(ctx, in)
+}
+
+// DecodeArgs unmarshals the JSON arguments of a tool call into a value of type T. Empty and null
+// arguments decode to the zero value. Decoding failures are reported as a *ValidationError naming the
+// offending field, such as "invalid argument 'address.zip': expected integer, got string", which reads
+// better when returned to the model than the errors of encoding/json. Unknown fields are ignored;
+// use DecodeArgsStrict to reject them.
+func DecodeArgs[T any](args json.RawMessage) (T, error) {
+	return decodeArgs[T](args, false)
+}
+
+// DecodeArgsStrict behaves like DecodeArgs but rejects fields that T does not declare.
+func DecodeArgsStrict[T any](args json.RawMessage) (T, error) {
+	return decodeArgs[T](args, true)
+}
+
+// decodeArgs implements DecodeArgs and DecodeArgsStrict.
+func decodeArgs[T any](args json.RawMessage, disallowUnknown bool) (T, error) {
+	var out T
+	if trimmed := bytes.TrimSpace(args); len(trimmed) == 0 || string(trimmed) == "null" {
+		return out, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(args))
+	if disallowUnknown {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&out); err != nil {
+		return out, describeDecodeError(err)
+	}
+	return out, nil
+}
+
+// describeDecodeError converts an error from encoding/json into a *ValidationError.
+func describeDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		got, _, _ := strings.Cut(typeErr.Value, " ")
+		if got == "bool" {
+			got = string(Boolean)
+		}
+		return &ValidationError{
+			Path:    decodePath(typeErr.Field),
+			Message: fmt.Sprintf("expected %s, got %s", describeGoType(typeErr.Type), got),
+		}
+	}
+	if field, found := strings.CutPrefix(err.Error(), `json: unknown field "`); found {
+		return &ValidationError{Path: strings.TrimSuffix(field, `"`), Message: "unknown field"}
+	}
+	return &ValidationError{Message: fmt.Sprintf("malformed JSON: %v", err)}
+}
+
+// decodePath rewrites a field path reported by encoding/json, such as "tags.2", in the notation of
+// ValidationError, such as "tags[2]".
+func decodePath(field string) string {
+	var path string
+	for _, segment := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(segment); err == nil && path != "" {
+			path += "[" + segment + "]"
+			continue
+		}
+		path = joinPath(path, segment)
+	}
+	return path
+}
+
+// describeGoType returns the JSON type a Go type is decoded from, such as "integer" for int64.
+func describeGoType(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == jsonNumberType:
+		return string(Number)
+	case t == timeType, t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return string(String)
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return string(Integer)
+	case reflect.Float32, reflect.Float64:
+		return string(Number)
+	case reflect.String:
+		return string(String)
+	case reflect.Bool:
+		return string(Boolean)
+	case reflect.Slice, reflect.Array:
+		return string(Array)
+	case reflect.Struct, reflect.Map:
+		return string(Object)
+	}
+	return t.String()
+}
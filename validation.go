@@ -0,0 +1,358 @@
+package syndicate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ValidationError describes a tool argument that does not conform to its schema.
+type ValidationError struct {
+	Path    string // Location of the offending value, e.g. "address.city" or "tags[2]".
+	Message string // Description of the violation.
+}
+
+// Error returns a human readable description of the validation failure.
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid arguments: %s", e.Message)
+	}
+	return fmt.Sprintf("invalid argument '%s': %s", e.Path, e.Message)
+}
+
+// ValidateArgs checks JSON arguments against a schema Definition before they reach a tool.
+// It verifies required fields, value types, enum membership, numeric bounds, string and array
+// lengths, patterns and closed objects. The first violation is returned as a *ValidationError.
+// Empty and null arguments, which models send for tools without parameters, are checked as {}.
+func ValidateArgs(def *Definition, args json.RawMessage) error {
+	if def == nil {
+		return nil
+	}
+	if trimmed := bytes.TrimSpace(args); len(trimmed) == 0 || string(trimmed) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(args))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("malformed JSON: %v", err)}
+	}
+
+	v := argsValidator{root: def}
+	return v.validate(def, value, "")
+}
+
+// argsValidator walks decoded arguments alongside their schema.
+type argsValidator struct {
+	root *Definition // Schema whose $defs and definitions resolve $ref.
+}
+
+// validate checks a single decoded JSON value against def.
+func (v argsValidator) validate(def *Definition, value any, path string) error {
+	if def.Ref != "" {
+		target, ok := lookupRef(v.root, def.Ref)
+		if !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unresolved reference %s", def.Ref)}
+		}
+		return v.validate(target, value, path)
+	}
+
+	if err := v.validateComposition(def, value, path); err != nil {
+		return err
+	}
+
+	// A const pins the value even for untyped subschemas such as if/then/else discriminators,
+	// so null only passes it when the definition is explicitly nullable.
+	if def.Const != nil && !(value == nil && def.Nullable) && !reflect.DeepEqual(normalizeJSONValue(def.Const), normalizeJSONValue(value)) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be %v", def.Const)}
+	}
+
+	// Likewise an enum lists every accepted value; nullable enums include null among them.
+	if len(def.Enum) > 0 && !enumContains(def.Enum, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be one of %v", def.Enum)}
+	}
+
+	if value == nil && (def.Nullable || def.Type == Null || def.Type == "") {
+		return nil
+	}
+
+	switch def.Type {
+	case Object:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return typeMismatch(path, def.Type, value)
+		}
+		return v.validateObject(def, obj, path)
+	case Array:
+		arr, ok := value.([]any)
+		if !ok {
+			return typeMismatch(path, def.Type, value)
+		}
+		return v.validateArray(def, arr, path)
+	case String:
+		str, ok := value.(string)
+		if !ok {
+			return typeMismatch(path, def.Type, value)
+		}
+		return validateString(def, str, path)
+	case Integer, Number:
+		num, ok := value.(json.Number)
+		if !ok {
+			return typeMismatch(path, def.Type, value)
+		}
+		return validateNumber(def, num, path)
+	case Boolean:
+		if _, ok := value.(bool); !ok {
+			return typeMismatch(path, def.Type, value)
+		}
+	case Null:
+		if value != nil {
+			return typeMismatch(path, def.Type, value)
+		}
+	case "":
+		// Untyped subschemas, such as the if of a conditional, constrain objects without requiring one.
+		if obj, ok := value.(map[string]any); ok && (len(def.Properties) > 0 || len(def.Required) > 0) {
+			return v.validateObject(def, obj, path)
+		}
+	}
+	return nil
+}
+
+// validateComposition checks the allOf, anyOf, oneOf and if/then/else keywords of def.
+func (v argsValidator) validateComposition(def *Definition, value any, path string) error {
+	for i := range def.AllOf {
+		if err := v.validate(&def.AllOf[i], value, path); err != nil {
+			return err
+		}
+	}
+	if len(def.AnyOf) > 0 && v.countMatches(def.AnyOf, value, path) == 0 {
+		return &ValidationError{Path: path, Message: "must match at least one of the anyOf schemas"}
+	}
+	if len(def.OneOf) > 0 && v.countMatches(def.OneOf, value, path) != 1 {
+		return &ValidationError{Path: path, Message: "must match exactly one of the oneOf schemas"}
+	}
+	if def.If != nil {
+		branch := def.Else
+		if v.validate(def.If, value, path) == nil {
+			branch = def.Then
+		}
+		if branch != nil {
+			return v.validate(branch, value, path)
+		}
+	}
+	return nil
+}
+
+// countMatches returns how many of the subschemas accept value.
+func (v argsValidator) countMatches(subschemas []Definition, value any, path string) int {
+	matches := 0
+	for i := range subschemas {
+		if v.validate(&subschemas[i], value, path) == nil {
+			matches++
+		}
+	}
+	return matches
+}
+
+// validateObject checks required fields, known properties and additional properties of an object.
+func (v argsValidator) validateObject(def *Definition, obj map[string]any, path string) error {
+	for _, name := range def.Required {
+		if _, ok := obj[name]; !ok {
+			return &ValidationError{Path: joinPath(path, name), Message: "required field is missing"}
+		}
+	}
+	// Report the first violation in a stable order: known properties, then the rest sorted.
+	for _, name := range propertyNames(def) {
+		value, ok := obj[name]
+		if !ok {
+			continue
+		}
+		prop := def.Properties[name]
+		if err := v.validate(&prop, value, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	extra := make([]string, 0, len(obj))
+	for name := range obj {
+		if _, ok := def.Properties[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		value := obj[name]
+		if def.AdditionalProperties.forbidden() {
+			return &ValidationError{Path: joinPath(path, name), Message: "unknown field"}
+		}
+		if def.describesMap() {
+			if err := v.validate(def.AdditionalProperties.Schema, value, joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateArray checks the item count, uniqueness and every item of an array.
+func (v argsValidator) validateArray(def *Definition, arr []any, path string) error {
+	if def.MinItems != nil && len(arr) < *def.MinItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must contain at least %d items", *def.MinItems)}
+	}
+	if def.MaxItems != nil && len(arr) > *def.MaxItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must contain at most %d items", *def.MaxItems)}
+	}
+	if def.UniqueItems {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if reflect.DeepEqual(normalizeJSONValue(arr[i]), normalizeJSONValue(arr[j])) {
+					return &ValidationError{Path: path, Message: fmt.Sprintf("items %d and %d are not unique", i, j)}
+				}
+			}
+		}
+	}
+	if def.Items != nil {
+		for i, item := range arr {
+			if err := v.validate(def.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateString checks the length and pattern constraints of a string.
+func validateString(def *Definition, str, path string) error {
+	length := utf8.RuneCountInString(str)
+	if def.MinLength != nil && length < *def.MinLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d characters long", *def.MinLength)}
+	}
+	if def.MaxLength != nil && length > *def.MaxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters long", *def.MaxLength)}
+	}
+	if def.Pattern != "" {
+		re, err := regexp.Compile(def.Pattern)
+		if err != nil {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("invalid pattern in schema: %v", err)}
+		}
+		if !re.MatchString(str) {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must match pattern %s", def.Pattern)}
+		}
+	}
+	return nil
+}
+
+// validateNumber checks that a number is integral when required and lies within its bounds.
+func validateNumber(def *Definition, num json.Number, path string) error {
+	f, err := strconv.ParseFloat(num.String(), 64)
+	if err != nil {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("invalid number %s", num)}
+	}
+	if def.Type == Integer && f != math.Trunc(f) {
+		return typeMismatch(path, def.Type, num)
+	}
+	if def.Minimum != nil && f < *def.Minimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be greater than or equal to %v", *def.Minimum)}
+	}
+	if def.Maximum != nil && f > *def.Maximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be less than or equal to %v", *def.Maximum)}
+	}
+	if def.ExclusiveMinimum != nil && f <= *def.ExclusiveMinimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be greater than %v", *def.ExclusiveMinimum)}
+	}
+	if def.ExclusiveMaximum != nil && f >= *def.ExclusiveMaximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be less than %v", *def.ExclusiveMaximum)}
+	}
+	if def.MultipleOf != nil && *def.MultipleOf > 0 {
+		// Allow for floating point error when dividing by fractional steps such as 0.01.
+		quotient := f / *def.MultipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be a multiple of %v", *def.MultipleOf)}
+		}
+	}
+	return nil
+}
+
+// typeMismatch builds the error returned when a value does not have the expected type.
+func typeMismatch(path string, want DataType, value any) error {
+	return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", want, describeJSONValue(value))}
+}
+
+// describeJSONValue returns the JSON type name of a decoded value for error messages.
+func describeJSONValue(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// enumContains reports whether value equals one of the enum values, comparing numbers by value.
+func enumContains(enum []any, value any) bool {
+	normalized := normalizeJSONValue(value)
+	for _, allowed := range enum {
+		if reflect.DeepEqual(normalizeJSONValue(allowed), normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeJSONValue converts numeric values to float64 so equal numbers compare equal
+// regardless of whether they were decoded or parsed from struct tags.
+func normalizeJSONValue(value any) any {
+	switch n := value.(type) {
+	case json.Number:
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	case []any:
+		out := make([]any, len(n))
+		for i, item := range n {
+			out[i] = normalizeJSONValue(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(n))
+		for key, item := range n {
+			out[key] = normalizeJSONValue(item)
+		}
+		return out
+	}
+	// Numbers of any Go numeric kind, including named types registered as enums, compare as float64.
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return value
+}
+
+// joinPath appends a property name to a dotted path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}